@@ -7,6 +7,7 @@ import (
 	"github.com/rancher/axe/throwing/k8s"
 	"github.com/rancher/axe/throwing/rio"
 	"os"
+	"time"
 
 	"github.com/rancher/axe/version"
 	"github.com/sirupsen/logrus"
@@ -36,6 +37,23 @@ func main() {
 			Name:   "blade",
 			Value:  "rio",
 		},
+		cli.StringFlag{
+			Name:   "field-manager",
+			EnvVar: "AXE_FIELD_MANAGER",
+			Usage:  "field manager of the changes applied by axe",
+			Value:  "axe",
+		},
+		cli.DurationFlag{
+			Name:   "completed-job-age",
+			EnvVar: "AXE_COMPLETED_JOB_AGE",
+			Usage:  "how long a completed job is kept before it is reported as unused",
+			Value:  24 * time.Hour,
+		},
+		cli.BoolFlag{
+			Name:   "journal-secrets",
+			EnvVar: "AXE_JOURNAL_SECRETS",
+			Usage:  "keep the values of deleted secrets in ~/.axe/deleted, in plaintext, they are left out otherwise",
+		},
 	}
 	app.Action = run
 
@@ -39,9 +39,19 @@ type AppView struct {
 	showMenu         bool
 	currentPage      string
 	currentPrimitive *TableView
+	terminals        *terminalTabs
 	switchPage       chan struct{}
 	syncs            map[string]chan struct{}
 	lock             sync.Mutex
+	// shown is the page shown, dialogs over it aside, and watches the work running while it is
+	shown   tview.Primitive
+	watches []pageWatch
+}
+
+// pageWatch is work running while a page is shown, its channel is closed once another page is.
+type pageWatch struct {
+	primitive tview.Primitive
+	stop      chan struct{}
 }
 
 type position struct {
@@ -158,6 +168,15 @@ func (app *AppView) getK8sVersion() (string, error) {
 }
 
 func (app *AppView) SwitchPage(page string, p tview.Primitive, actions []types.Action) {
+	app.showPage(page, p, actions, false)
+}
+
+// showDialog shows a dialog over the current page, which is still the page shown for the work running while it is.
+func (app *AppView) showDialog(p tview.Primitive, actions []types.Action) {
+	app.showPage(app.currentPage, p, actions, true)
+}
+
+func (app *AppView) showPage(page string, p tview.Primitive, actions []types.Action, dialog bool) {
 	app.Menu = actions
 	app.menuView.TextView.Clear()
 	app.menuView.init()
@@ -167,12 +186,21 @@ func (app *AppView) SwitchPage(page string, p tview.Primitive, actions []types.A
 		if _, ok := p.(*TableView); ok {
 			app.currentPrimitive = p.(*TableView)
 		}
+		if tv, ok := p.(*TreeView); ok {
+			app.currentPrimitive = tv.table
+		}
 		if cp != "" {
 			go func() {
 				app.switchPage <- struct{}{}
 			}()
 		}
 	}
+	if t, ok := p.(*TableView); ok {
+		p = t.view()
+	}
+	if !dialog {
+		app.setShown(p)
+	}
 	app.content.AddAndSwitchToPage(page, p, true)
 
 	app.drawQueue.Enqueue(PageTrack{
@@ -182,6 +210,43 @@ func (app *AppView) SwitchPage(page string, p tview.Primitive, actions []types.A
 	app.SetFocus(p)
 }
 
+// setShown records the page shown, stops the work of the page left and starts refreshing the table shown if it asked to.
+func (app *AppView) setShown(p tview.Primitive) {
+	app.lock.Lock()
+	if p == app.shown {
+		app.lock.Unlock()
+		return
+	}
+	app.shown = p
+	var kept []pageWatch
+	for _, w := range app.watches {
+		if w.primitive == p {
+			kept = append(kept, w)
+			continue
+		}
+		close(w.stop)
+	}
+	app.watches = kept
+	app.lock.Unlock()
+
+	if t := pageTable(p); t != nil && t.refreshInterval > 0 {
+		t.refreshWhileShown()
+	}
+}
+
+// whileShown returns a channel closed once another page than p is shown, it is closed already if p is not shown.
+func (app *AppView) whileShown(p tview.Primitive) <-chan struct{} {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	stop := make(chan struct{})
+	if p != app.shown {
+		close(stop)
+		return stop
+	}
+	app.watches = append(app.watches, pageWatch{primitive: p, stop: stop})
+	return stop
+}
+
 func (app *AppView) SwitchToRootPage() {
 	app.showMenu = false
 	app.SwitchPage(app.currentPage, app.tableViews[app.currentPage], app.tableViews[app.currentPage].actions)
@@ -191,10 +256,36 @@ func (app *AppView) CurrentPage() tview.Primitive {
 	return app.currentPrimitive
 }
 
+/*
+LastPage goes back to the previous table or tree. Pages shown on top of them (dialogs, get, logs...) are skipped, so
+that walking back from a drill-down lands on the parent table rather than on a view opened from it.
+*/
 func (app *AppView) LastPage() {
-	app.drawQueue.Dequeue()
+	current := app.drawQueue.Dequeue()
 	page := app.drawQueue.Last()
-	app.SwitchPage(page.PageName, page.Primitive, page.Primitive.(*TableView).actions)
+	for !app.drawQueue.Empty() {
+		if pageTable(page.Primitive) != nil && page.Primitive != current.Primitive {
+			break
+		}
+		app.drawQueue.Dequeue()
+		page = app.drawQueue.Last()
+	}
+	var actions []types.Action
+	if t := pageTable(page.Primitive); t != nil {
+		actions = t.actions
+	}
+	app.SwitchPage(page.PageName, page.Primitive, actions)
+}
+
+// pageTable returns the table behind a page if the page is a table or a tree.
+func pageTable(p tview.Primitive) *TableView {
+	switch v := p.(type) {
+	case *TableView:
+		return v
+	case *TreeView:
+		return v.table
+	}
+	return nil
 }
 
 type menuView struct {
@@ -0,0 +1,40 @@
+package throwing
+
+import (
+	"testing"
+
+	"github.com/rivo/tview"
+)
+
+func closed(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestWhileShown(t *testing.T) {
+	app := &AppView{}
+	page, other := tview.NewBox(), tview.NewBox()
+
+	if !closed(app.whileShown(page)) {
+		t.Errorf("whileShown() of a page not shown is open")
+	}
+
+	app.setShown(page)
+	stop := app.whileShown(page)
+	app.setShown(page)
+	if closed(stop) {
+		t.Errorf("whileShown() closed when the page is shown again")
+	}
+
+	app.setShown(other)
+	if !closed(stop) {
+		t.Errorf("whileShown() open once another page is shown")
+	}
+	if len(app.watches) != 0 {
+		t.Errorf("%d watches kept once their page was left", len(app.watches))
+	}
+}
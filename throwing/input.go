@@ -7,6 +7,14 @@ import (
 var (
 	EscapeEventHandler = func(app *AppView) func(event *tcell.EventKey) *tcell.EventKey {
 		return func(event *tcell.EventKey) *tcell.EventKey {
+			// every key belongs to the remote process while a terminal has focus
+			if _, ok := app.GetFocus().(*TerminalView); ok {
+				return event
+			}
+			// tables handle these keys themselves, e.g. to walk back up a drill-down
+			if t, ok := app.tableViews[app.currentPage]; ok && app.GetFocus() == t.view() {
+				return event
+			}
 			if event.Key() == tcell.KeyEscape || event.Rune() == 'q' {
 				app.showMenu = false
 				app.SwitchPage(app.currentPage, app.tableViews[app.currentPage], app.tableViews[app.currentPage].actions)
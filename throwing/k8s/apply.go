@@ -0,0 +1,448 @@
+package k8s
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/ghodss/yaml"
+	"github.com/rancher/axe/throwing"
+	"github.com/rancher/axe/throwing/datafeeder"
+	"github.com/rancher/axe/throwing/types"
+	"github.com/rivo/tview"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	applyKind = "apply"
+
+	// applyPatchType is the patch type of server-side apply, which the vendored apimachinery predates
+	applyPatchType = k8stypes.PatchType("application/apply-patch+yaml")
+
+	applyCreate  = "create"
+	applyUpdate  = "update"
+	applyInvalid = "invalid"
+	// applyPending is the action of objects of kinds not served yet, such as custom resources of a definition applied first
+	applyPending = "pending"
+
+	// kindWait is how long a kind is waited for once definitions were applied, until the server establishes them
+	kindWait = 10 * time.Second
+)
+
+var (
+	// fieldManager is the manager the fields written by axe are owned by
+	fieldManager = "axe"
+
+	applyActions = []types.Action{
+		{
+			Name:        "apply",
+			Shortcut:    "y",
+			Description: "apply the objects",
+		},
+	}
+
+	manifestExtensions = map[string]bool{
+		".yaml": true,
+		".yml":  true,
+		".json": true,
+	}
+)
+
+// resourcePath returns the API path of an object, or of its resource if name is empty.
+func resourcePath(r apiResource, namespace, name string) string {
+	path := "/apis/" + r.gvr.Group + "/" + r.gvr.Version
+	if r.gvr.Group == "" {
+		path = "/api/" + r.gvr.Version
+	}
+	if r.namespaced {
+		path += "/namespaces/" + namespace
+	}
+	path += "/" + r.gvr.Resource
+	if name != "" {
+		path += "/" + name
+	}
+	return path
+}
+
+/*
+serverSideApply applies an object with server-side apply under the field manager of axe, forcing conflicts with other
+managers if asked to. The dynamic client of this version can not pass a field manager, so the request is sent with the
+REST client of discovery, which has no prefix.
+*/
+func serverSideApply(clientset *kubernetes.Clientset, r apiResource, obj *unstructured.Unstructured, force, dryRun bool) (*unstructured.Unstructured, error) {
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return nil, err
+	}
+	req := clientset.Discovery().RESTClient().Patch(applyPatchType).
+		AbsPath(resourcePath(r, obj.GetNamespace(), obj.GetName())).
+		Param("fieldManager", fieldManager).
+		Body(data)
+	if force {
+		req = req.Param("force", "true")
+	}
+	if dryRun {
+		req = req.Param("dryRun", metav1.DryRunAll)
+	}
+	raw, err := req.Do().Raw()
+	if err != nil {
+		return nil, err
+	}
+	result := &unstructured.Unstructured{}
+	return result, result.UnmarshalJSON(raw)
+}
+
+// readManifests reads the objects of a file, or of the manifest files of a directory and its subdirectories.
+func readManifests(path string) ([]*unstructured.Unstructured, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	if info.IsDir() {
+		err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && manifestExtensions[filepath.Ext(file)] {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+	} else {
+		files = []string{path}
+	}
+
+	var objects []*unstructured.Unstructured
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := parseManifests(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		objects = append(objects, parsed...)
+	}
+	return objects, nil
+}
+
+// parseManifests parses the documents of a multi-document YAML, the items of lists are returned as objects.
+func parseManifests(data []byte) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	for i, doc := range splitDocuments(data) {
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal(doc, &obj); err != nil {
+			return nil, fmt.Errorf("document %d: %v", i+1, err)
+		}
+		if len(obj) == 0 {
+			continue
+		}
+		u := &unstructured.Unstructured{Object: obj}
+		if u.IsList() {
+			list, err := u.ToList()
+			if err != nil {
+				return nil, fmt.Errorf("document %d: %v", i+1, err)
+			}
+			for j := range list.Items {
+				objects = append(objects, &list.Items[j])
+			}
+			continue
+		}
+		if u.GetAPIVersion() == "" || u.GetKind() == "" {
+			return nil, fmt.Errorf("document %d has no apiVersion or kind", i+1)
+		}
+		objects = append(objects, u)
+	}
+	return objects, nil
+}
+
+func splitDocuments(data []byte) [][]byte {
+	var docs [][]byte
+	doc := &bytes.Buffer{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "---" {
+			docs = append(docs, doc.Bytes())
+			doc = &bytes.Buffer{}
+			continue
+		}
+		doc.WriteString(line + "\n")
+	}
+	return append(docs, doc.Bytes())
+}
+
+// applyItem is an object to apply, with what applying it does.
+type applyItem struct {
+	object   *unstructured.Unstructured
+	resource apiResource
+	action   string
+	result   string
+}
+
+/*
+applyPlan is the DataSource of the preview of the objects to apply, which then shows the result for each of them. The
+objects are applied in the background, the lock guards what the table reads of the items meanwhile.
+*/
+type applyPlan struct {
+	clientset *kubernetes.Clientset
+	namespace string
+	items     []*applyItem
+	lock      sync.Mutex
+	applying  bool
+}
+
+/*
+newApplyPlan resolves the resources of the objects and whether they exist already. Objects of namespaced resources
+without a namespace go to the namespace given. Kinds the server does not serve are left pending: they may be defined by
+objects applied before them, and are resolved again when applied.
+*/
+func newApplyPlan(clientset *kubernetes.Clientset, objects []*unstructured.Unstructured, namespace string) *applyPlan {
+	plan := &applyPlan{clientset: clientset, namespace: namespace}
+	for _, obj := range objects {
+		item := &applyItem{object: obj}
+		plan.items = append(plan.items, item)
+
+		r, err := lookupKind(clientset, obj.GetAPIVersion(), obj.GetKind())
+		if err != nil {
+			item.action, item.result = applyPending, err.Error()
+			continue
+		}
+		plan.resolve(item, r)
+	}
+	return plan
+}
+
+// resolve sets the resource of an item, and whether applying it creates or updates the object.
+func (p *applyPlan) resolve(item *applyItem, r apiResource) {
+	obj := item.object
+	namespace := obj.GetNamespace()
+	if !r.namespaced {
+		namespace = ""
+	} else if namespace == "" {
+		namespace = p.namespace
+	}
+	p.lock.Lock()
+	item.resource = r
+	obj.SetNamespace(namespace)
+	p.lock.Unlock()
+
+	client, err := resourceClient(r, namespace)
+	if err != nil {
+		p.set(item, applyInvalid, err.Error())
+		return
+	}
+	_, err = client.Get(obj.GetName(), metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		p.set(item, applyCreate, "")
+	case err != nil:
+		p.set(item, applyInvalid, err.Error())
+	default:
+		p.set(item, applyUpdate, "")
+	}
+}
+
+func (p *applyPlan) set(item *applyItem, action, result string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	item.action, item.result = action, result
+}
+
+// waitForKind resolves the kind of a pending item, waiting for the server to serve it if definitions were applied.
+func (p *applyPlan) waitForKind(item *applyItem, definitions bool) error {
+	var r apiResource
+	err := wait.PollImmediate(time.Second, kindWait, func() (bool, error) {
+		var err error
+		r, err = lookupKind(p.clientset, item.object.GetAPIVersion(), item.object.GetKind())
+		if err != nil && !definitions {
+			return false, err
+		}
+		return err == nil, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("kind %s not found in %s", item.object.GetKind(), item.object.GetAPIVersion())
+	}
+	if err != nil {
+		return err
+	}
+	p.resolve(item, r)
+	if item.action == applyInvalid {
+		return fmt.Errorf("%s", item.result)
+	}
+	return nil
+}
+
+func (p *applyPlan) Header() datafeeder.Row {
+	return datafeeder.Row{"KIND", "NAMESPACE", "NAME", "ACTION", "RESULT"}
+}
+
+func (p *applyPlan) Data() []datafeeder.Row {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var rows []datafeeder.Row
+	for _, item := range p.items {
+		rows = append(rows, datafeeder.Row{
+			item.object.GetKind(),
+			item.object.GetNamespace(),
+			item.object.GetName(),
+			item.action,
+			strings.Join(strings.Fields(item.result), " "),
+		})
+	}
+	return rows
+}
+
+func (p *applyPlan) Refresh() error {
+	return nil
+}
+
+/*
+apply applies the valid objects in order, so that e.g. a namespace is created before the objects in it, and custom
+resources once their definition is. It is called in the background, progress is called once each object is done.
+*/
+func (p *applyPlan) apply(progress func()) (int, int) {
+	applied, failed := 0, 0
+	definitions := false
+	for _, item := range p.items {
+		if item.action == applyInvalid {
+			continue
+		}
+		if item.action == applyPending {
+			if err := p.waitForKind(item, definitions); err != nil {
+				p.set(item, item.action, "failed: "+err.Error())
+				failed++
+				progress()
+				continue
+			}
+		}
+		if _, err := serverSideApply(p.clientset, item.resource, item.object, false, false); err != nil {
+			p.set(item, item.action, "failed: "+err.Error())
+			failed++
+			progress()
+			continue
+		}
+		p.set(item, item.action, "applied")
+		applied++
+		progress()
+		if item.object.GetKind() == "CustomResourceDefinition" {
+			definitions = true
+		}
+	}
+	return applied, failed
+}
+
+func applyEventHandler(p *applyPlan) throwing.EventHandler {
+	return func(t *throwing.TableView) func(event *tcell.EventKey) *tcell.EventKey {
+		return func(event *tcell.EventKey) *tcell.EventKey {
+			switch event.Key() {
+			case tcell.KeyEscape:
+				t.BackPage()
+			case tcell.KeyRune:
+				switch event.Rune() {
+				case 'y':
+					if p.applying {
+						return event
+					}
+					confirm(t, "apply", fmt.Sprintf("Do you want to apply %d objects as %s?", len(p.items), fieldManager), func() {
+						p.applying = true
+						// close the dialog back to the plan page, where each object gets its result once applied
+						t.BackPage()
+						t.GetTable().SetTitle("apply - applying")
+						app := t.GetApplication()
+						go func() {
+							applied, failed := p.apply(func() {
+								app.QueueUpdateDraw(t.RefreshManual)
+							})
+							app.QueueUpdateDraw(func() {
+								t.GetTable().SetTitle(fmt.Sprintf("apply - %d applied, %d failed", applied, failed))
+								t.RefreshManual()
+							})
+						}()
+					})
+				case 'q':
+					t.RootPage()
+				}
+			}
+			return event
+		}
+	}
+}
+
+// previewApply opens the preview of the objects to apply, they are only applied from there.
+func previewApply(t *throwing.TableView, source string, objects []*unstructured.Unstructured) {
+	if len(objects) == 0 {
+		t.UpdateStatus(fmt.Sprintf("no objects found in %s", source), true)
+		return
+	}
+	plan := newApplyPlan(t.GetClientSet(), objects, currentNamespace(t))
+	kind := types.ResourceKind{
+		Title: fmt.Sprintf("apply - (%s) [y] apply", source),
+		Kind:  applyKind,
+	}
+	newtable := t.NewNestTableView(kind, plan, applyActions, nil, applyEventHandler(plan))
+	t.SetTableView(applyKind, newtable)
+	t.SwitchPage(applyKind, newtable)
+}
+
+// currentNamespace returns the namespace of the current row, or default.
+func currentNamespace(t *throwing.TableView) string {
+	if t.GetResourceKind() == "namespaces" {
+		_, name := getNamespaceAndName(t)
+		return name
+	}
+	if namespace, _ := getNamespaceAndName(t); namespace != "" {
+		return namespace
+	}
+	return "default"
+}
+
+func applyDialog(t *throwing.TableView) {
+	path := ""
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle("apply - file or directory")
+	form.AddInputField("Path", "", 40, nil, func(text string) {
+		path = strings.TrimSpace(text)
+	})
+	form.AddButton("preview", func() {
+		if strings.HasPrefix(path, "~/") {
+			path = filepath.Join(os.Getenv("HOME"), path[2:])
+		}
+		objects, err := readManifests(path)
+		if err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		t.BackPage()
+		previewApply(t, path, objects)
+	})
+	form.AddButton("Cancel", func() {
+		t.BackPage()
+	})
+	form.SetCancelFunc(func() {
+		t.BackPage()
+	})
+	t.InsertDialog("apply", t.GetCurrentPrimitive(), form)
+}
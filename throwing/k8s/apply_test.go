@@ -0,0 +1,60 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseManifests(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "documents",
+			data: "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: a}\n---\napiVersion: v1\nkind: Secret\nmetadata: {name: b}\n",
+			want: []string{"ConfigMap/a", "Secret/b"},
+		},
+		{
+			name: "empty documents and comments",
+			data: "---\n# nothing\n---\napiVersion: v1\nkind: ConfigMap\nmetadata: {name: a}\n---\n",
+			want: []string{"ConfigMap/a"},
+		},
+		{
+			name: "list",
+			data: "apiVersion: v1\nkind: List\nitems:\n- {apiVersion: v1, kind: ConfigMap, metadata: {name: a}}\n- {apiVersion: v1, kind: Service, metadata: {name: b}}\n",
+			want: []string{"ConfigMap/a", "Service/b"},
+		},
+		{
+			name: "json",
+			data: `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "a"}}`,
+			want: []string{"Namespace/a"},
+		},
+		{
+			name:    "no kind",
+			data:    "apiVersion: v1\nmetadata: {name: a}\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid yaml",
+			data:    "apiVersion: v1\nkind: [\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		objects, err := parseManifests([]byte(tt.data))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: parseManifests() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		var got []string
+		for _, obj := range objects {
+			got = append(got, obj.GetKind()+"/"+obj.GetName())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseManifests() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rancher/axe/throwing"
+	"github.com/rivo/tview"
+)
+
+// objectName is how an object is named in messages, with its namespace if it has one.
+func objectName(o objectRef) string {
+	if o.namespace == "" {
+		return o.name
+	}
+	return o.namespace + "/" + o.name
+}
+
+/*
+applyToObjects runs an action on the selected objects, once they were confirmed. A failure on a single object is shown
+in the status bar, with several objects the marks are cleared and every object gets a line in a summary page.
+*/
+func applyToObjects(t *throwing.TableView, verb string, objects []objectRef, apply func(o objectRef) error) {
+	if len(objects) == 1 {
+		if err := apply(objects[0]); err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		t.SwitchToRootPage()
+		t.RefreshManual()
+		return
+	}
+
+	var lines []string
+	failed := 0
+	for _, o := range objects {
+		if err := apply(o); err != nil {
+			failed++
+			lines = append(lines, fmt.Sprintf("[red]failed[white]  %s: %s", tview.Escape(objectName(o)), tview.Escape(err.Error())))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("[green]ok[white]      %s", tview.Escape(objectName(o))))
+	}
+	t.ClearMarks()
+	t.SwitchToRootPage()
+	t.RefreshManual()
+	title := fmt.Sprintf("%s - %d of %d %s succeeded", verb, len(objects)-failed, len(objects), t.GetResourceKind())
+	showText(t, verb+"-results", title, strings.Join(lines, "\n"))
+}
+
+// describeObjects names the selected objects in a confirmation, listing a few of them when there are several.
+func describeObjects(t *throwing.TableView, objects []objectRef) string {
+	if len(objects) == 1 {
+		return fmt.Sprintf("%s %s", t.GetResourceKind(), objects[0].name)
+	}
+	var names []string
+	for i, o := range objects {
+		if i == maxUsagesShown {
+			names = append(names, fmt.Sprintf("and %d more", len(objects)-i))
+			break
+		}
+		names = append(names, objectName(o))
+	}
+	return fmt.Sprintf("%d %s (%s)", len(objects), t.GetResourceKind(), strings.Join(names, ", "))
+}
+
+// markDialog marks the rows with a cell containing the text entered.
+func markDialog(t *throwing.TableView) {
+	filter := ""
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle("mark - rows containing")
+	form.AddInputField("Filter", "", 30, nil, func(text string) {
+		filter = text
+	})
+	form.AddButton("mark", func() {
+		t.BackPage()
+		if filter == "" {
+			return
+		}
+		if t.MarkMatching(filter) == 0 {
+			t.UpdateStatus(fmt.Sprintf("no row contains %q", filter), true)
+		}
+	})
+	form.AddButton("Cancel", func() {
+		t.BackPage()
+	})
+	form.SetCancelFunc(func() {
+		t.BackPage()
+	})
+	t.InsertDialog("mark", t.GetCurrentPrimitive(), form)
+}
@@ -0,0 +1,294 @@
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/rancher/axe/throwing"
+	"github.com/rivo/tview"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+/*
+diffObjects compares the two marked rows side by side, or the object of the current row against its last applied
+configuration. The marked rows may be of different namespaces, compareContextDialog compares with another cluster.
+*/
+func diffObjects(t *throwing.TableView) {
+	objects := selectedObjects(t)
+	switch len(objects) {
+	case 1:
+		diffLastApplied(t, objects[0])
+	case 2:
+		compareObjects(t, objects[0], objects[1])
+	default:
+		t.UpdateStatus(fmt.Sprintf("mark two rows to compare them, %d are marked", len(objects)), true)
+	}
+}
+
+var (
+	// mergeKeys are the fields list items are matched on, in order, as the strategic merge keys of the core types
+	mergeKeys = []string{"name", "containerPort", "port", "mountPath", "devicePath", "ip", "type"}
+)
+
+func getObject(t *throwing.TableView, o objectRef) (*unstructured.Unstructured, error) {
+	r, err := lookupResource(t.GetClientSet(), t.GetResourceKind())
+	if err != nil {
+		return nil, err
+	}
+	client, err := resourceClient(r, o.namespace)
+	if err != nil {
+		return nil, err
+	}
+	return client.Get(o.name, metav1.GetOptions{})
+}
+
+/*
+diffLastApplied diffs the configuration last applied with kubectl against the live object. Only the fields of the
+configuration are compared, the others are set by the server or by controllers and are not drift.
+*/
+func diffLastApplied(t *throwing.TableView, o objectRef) {
+	live, err := getObject(t, o)
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	lastApplied, ok := live.GetAnnotations()[lastAppliedAnnotation]
+	if !ok {
+		t.UpdateStatus(fmt.Sprintf("%s has no %s annotation", o.name, lastAppliedAnnotation), true)
+		return
+	}
+	applied := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(lastApplied), &applied); err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+
+	projected, _ := projectFields(live.Object, applied).(map[string]interface{})
+	from, err := yaml.Marshal(applied)
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	to, err := yaml.Marshal(projected)
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	diff := unifiedDiff(string(from), string(to), "last-applied", "live")
+	if diff == "" {
+		diff = "the live object matches its last applied configuration"
+	}
+	showText(t, "diff", fmt.Sprintf("diff - (%s) last-applied vs live", o.name), colorDiff(diff))
+}
+
+/*
+projectFields returns the fields of live found in fields. The items of a list are matched by their merge key, such as
+the name of a container or the port of a service, and by index when they have none, so that what the server defaults
+in them is left out as well.
+*/
+func projectFields(live, fields interface{}) interface{} {
+	if liveList, ok := live.([]interface{}); ok {
+		if fieldsList, ok := fields.([]interface{}); ok {
+			return projectItems(liveList, fieldsList)
+		}
+		return live
+	}
+	liveMap, ok := live.(map[string]interface{})
+	fieldsMap, isMap := fields.(map[string]interface{})
+	if !ok || !isMap {
+		return live
+	}
+	projected := map[string]interface{}{}
+	for k, v := range fieldsMap {
+		if lv, ok := liveMap[k]; ok {
+			projected[k] = projectFields(lv, v)
+		}
+	}
+	return projected
+}
+
+func projectItems(live, fields []interface{}) []interface{} {
+	projected := []interface{}{}
+	for i, item := range fields {
+		if lv, ok := matchingItem(live, i, item); ok {
+			projected = append(projected, projectFields(lv, item))
+		}
+	}
+	return projected
+}
+
+// matchingItem returns the item of live that an item of a configuration is for.
+func matchingItem(live []interface{}, i int, item interface{}) (interface{}, bool) {
+	if fields, ok := item.(map[string]interface{}); ok {
+		for _, key := range mergeKeys {
+			value, ok := fields[key]
+			if !ok {
+				continue
+			}
+			for _, lv := range live {
+				if lm, ok := lv.(map[string]interface{}); ok && fmt.Sprint(lm[key]) == fmt.Sprint(value) {
+					return lv, true
+				}
+			}
+			return nil, false
+		}
+	}
+	if i < len(live) {
+		return live[i], true
+	}
+	return nil, false
+}
+
+// normalizedManifest returns the manifest of an object without what differs between copies of it in other namespaces.
+func normalizedManifest(obj *unstructured.Unstructured) (string, error) {
+	cleaned := cleanManifest(obj)
+	unstructured.RemoveNestedField(cleaned.Object, "metadata", "namespace")
+	unstructured.RemoveNestedField(cleaned.Object, "metadata", "annotations", lastAppliedAnnotation)
+	if len(cleaned.GetAnnotations()) == 0 {
+		unstructured.RemoveNestedField(cleaned.Object, "metadata", "annotations")
+	}
+	data, err := yaml.Marshal(cleaned.Object)
+	return string(data), err
+}
+
+// compareObjects shows two objects of the same resource side by side, e.g. to find how environments drifted.
+func compareObjects(t *throwing.TableView, a, b objectRef) {
+	objA, err := getObject(t, a)
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	objB, err := getObject(t, b)
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	showComparison(t, objA, objB, objectName(a), objectName(b))
+}
+
+func showComparison(t *throwing.TableView, a, b *unstructured.Unstructured, nameA, nameB string) {
+	manifestA, err := normalizedManifest(a)
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	manifestB, err := normalizedManifest(b)
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	title := fmt.Sprintf("compare - (%s) vs (%s)", nameA, nameB)
+	if manifestA == manifestB {
+		title += " identical"
+	}
+	showText(t, "compare", title, sideBySide(manifestA, manifestB, nameA, nameB))
+}
+
+// kubeconfigContexts returns the contexts of the kubeconfig axe runs with, sorted, and the current one.
+func kubeconfigContexts() ([]string, string, error) {
+	rules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: os.Getenv("KUBECONFIG")}
+	config, err := rules.Load()
+	if err != nil {
+		return nil, "", err
+	}
+	var contexts []string
+	for name := range config.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+	return contexts, config.CurrentContext, nil
+}
+
+// contextObject reads an object from the cluster of another context of the kubeconfig.
+func contextObject(context string, r apiResource, namespace, name string) (*unstructured.Unstructured, error) {
+	rules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: os.Getenv("KUBECONFIG")}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: context}
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	if !r.namespaced {
+		return client.Resource(r.gvr).Get(name, metav1.GetOptions{})
+	}
+	return client.Resource(r.gvr).Namespace(namespace).Get(name, metav1.GetOptions{})
+}
+
+/*
+compareContextDialog compares the object of the current row with an object of another context of the kubeconfig, the
+one of the same namespace and name unless changed. It is read in the version of the resource of this cluster.
+*/
+func compareContextDialog(t *throwing.TableView) {
+	objects := selectedObjects(t)
+	if len(objects) != 1 {
+		t.UpdateStatus(fmt.Sprintf("mark one row to compare it with another context, %d are marked", len(objects)), true)
+		return
+	}
+	o := objects[0]
+	r, err := lookupResource(t.GetClientSet(), t.GetResourceKind())
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	contexts, current, err := kubeconfigContexts()
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	var others []string
+	for _, c := range contexts {
+		if c != current {
+			others = append(others, c)
+		}
+	}
+	if len(others) == 0 {
+		t.UpdateStatus("the kubeconfig has no other context to compare with", true)
+		return
+	}
+
+	context, namespace, name := others[0], o.namespace, o.name
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle(fmt.Sprintf("compare - (%s) with another context", objectName(o)))
+	form.AddDropDown("Context", others, 0, func(option string, index int) {
+		context = option
+	})
+	if r.namespaced {
+		form.AddInputField("Namespace", namespace, 30, nil, func(text string) {
+			namespace = strings.TrimSpace(text)
+		})
+	}
+	form.AddInputField("Name", name, 30, nil, func(text string) {
+		name = strings.TrimSpace(text)
+	})
+	form.AddButton("compare", func() {
+		live, err := getObject(t, o)
+		if err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		other, err := contextObject(context, r, namespace, name)
+		if err != nil {
+			t.UpdateStatus(fmt.Sprintf("%s: %v", context, err), true)
+			return
+		}
+		t.BackPage()
+		otherName := context + ": " + objectName(objectRef{namespace, name})
+		showComparison(t, live, other, current+": "+objectName(o), otherName)
+	})
+	form.AddButton("Cancel", func() {
+		t.BackPage()
+	})
+	form.SetCancelFunc(func() {
+		t.BackPage()
+	})
+	t.InsertDialog("compare", t.GetCurrentPrimitive(), form)
+}
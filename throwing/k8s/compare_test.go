@@ -0,0 +1,66 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProjectFields(t *testing.T) {
+	live := parseYAML(t, `
+metadata:
+  name: web
+  uid: 1234
+spec:
+  ports:
+  - {name: http, port: 80, protocol: TCP, targetPort: 8080}
+  - {name: https, port: 443, protocol: TCP, targetPort: 8443}
+  template:
+    spec:
+      containers:
+      - {name: web, image: 'nginx:1', imagePullPolicy: IfNotPresent}
+      - {name: sidecar, image: 'envoy:1', imagePullPolicy: IfNotPresent}
+      args: [a, b]
+`)
+	tests := []struct {
+		name   string
+		fields string
+		want   string
+	}{
+		{
+			name:   "server fields left out",
+			fields: "metadata: {name: web}",
+			want:   "metadata: {name: web}",
+		},
+		{
+			name:   "defaults of list items left out",
+			fields: "spec: {template: {spec: {containers: [{name: web, image: 'nginx:1'}]}}}",
+			want:   "spec: {template: {spec: {containers: [{name: web, image: 'nginx:1'}]}}}",
+		},
+		{
+			name:   "items matched by key, not index",
+			fields: "spec: {ports: [{name: https, port: 443}]}",
+			want:   "spec: {ports: [{name: https, port: 443}]}",
+		},
+		{
+			name:   "item missing from live",
+			fields: "spec: {ports: [{name: metrics, port: 9090}, {name: http, port: 80}]}",
+			want:   "spec: {ports: [{name: http, port: 80}]}",
+		},
+		{
+			name:   "scalar items by index",
+			fields: "spec: {template: {spec: {args: [x]}}}",
+			want:   "spec: {template: {spec: {args: [a]}}}",
+		},
+		{
+			name:   "field missing from live",
+			fields: "spec: {replicas: 2}",
+			want:   "spec: {}",
+		},
+	}
+	for _, tt := range tests {
+		got := projectFields(live, parseYAML(t, tt.fields))
+		if !reflect.DeepEqual(got, parseYAML(t, tt.want)) {
+			t.Errorf("%s: projectFields() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
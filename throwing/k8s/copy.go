@@ -0,0 +1,683 @@
+package k8s
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/rancher/axe/throwing"
+	"github.com/rancher/axe/throwing/datafeeder"
+	"github.com/rancher/axe/throwing/types"
+	"github.com/rivo/tview"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	filesKind = "files"
+
+	// copyProgressInterval is how often the progress of a copy is redrawn
+	copyProgressInterval = 200 * time.Millisecond
+
+	fileTypeDir  = "dir"
+	fileTypeFile = "file"
+	fileTypeLink = "link"
+)
+
+var (
+	filesActions = []types.Action{
+		{
+			Name:        "open",
+			Shortcut:    "Enter",
+			Description: "open a directory",
+		},
+		{
+			Name:        "download",
+			Shortcut:    "d",
+			Description: "download a file or directory",
+		},
+		{
+			Name:        "upload",
+			Shortcut:    "u",
+			Description: "upload a local file or directory here",
+		},
+	}
+
+	errCopyCanceled = errors.New("copy canceled")
+)
+
+// containerFile is an entry of a directory of a container, as listed by ls.
+type containerFile struct {
+	name     string
+	fileType string
+	size     string
+	modified string
+	target   string
+}
+
+/*
+containerFiles is the DataSource of the file browser of a container. Directories are listed with `ls -lA` over exec, so
+the image has to provide ls, as it has to provide tar to copy files.
+*/
+type containerFiles struct {
+	t         *throwing.TableView
+	namespace string
+	pod       string
+	container string
+	// dir is the directory to list, shown is the one listed last, which is kept if dir can not be listed
+	dir     string
+	shown   string
+	entries []containerFile
+}
+
+func (f *containerFiles) Header() datafeeder.Row {
+	return datafeeder.Row{"NAME", "TYPE", "SIZE", "MODIFIED", "TARGET"}
+}
+
+func (f *containerFiles) Data() []datafeeder.Row {
+	rows := []datafeeder.Row{{"..", fileTypeDir, "", "", ""}}
+	for _, e := range f.entries {
+		rows = append(rows, datafeeder.Row{e.name, e.fileType, e.size, e.modified, e.target})
+	}
+	return rows
+}
+
+func (f *containerFiles) Refresh() error {
+	// the trailing slash lists the directory a link points to, not the link
+	dir := strings.TrimSuffix(f.dir, "/") + "/"
+	out := &bytes.Buffer{}
+	if err := runInContainer(f.t, f.namespace, f.pod, f.container, []string{"ls", "-lA", "--", dir}, nil, out); err != nil {
+		f.dir = f.shown
+		return err
+	}
+	f.entries = parseListing(out.String())
+	f.shown = f.dir
+	return nil
+}
+
+func (f *containerFiles) entry(name string) (containerFile, bool) {
+	for _, e := range f.entries {
+		if e.name == name {
+			return e, true
+		}
+	}
+	return containerFile{}, false
+}
+
+func (f *containerFiles) title() string {
+	return fmt.Sprintf("files - %s/%s:%s [Enter] open [d] download [u] upload", f.pod, f.container, f.shown)
+}
+
+// open lists another directory, the table is left as is if it can not be listed.
+func (f *containerFiles) open(t *throwing.TableView, dir string) {
+	f.dir = dir
+	t.RefreshManual()
+	t.GetTable().SetTitle(f.title())
+	t.GetTable().Select(1, 0)
+}
+
+/*
+parseListing parses the output of `ls -lA`, of GNU coreutils or busybox: mode, links, owner, group, size, three fields of
+date and the name, followed by ` -> target` for links. Devices have `major, minor` in place of the size.
+*/
+func parseListing(output string) []containerFile {
+	var files []containerFile
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" || strings.HasPrefix(line, "total ") {
+			continue
+		}
+		fields, rest := cutFields(line, 5)
+		if len(fields) < 5 {
+			continue
+		}
+		size := fields[4]
+		if strings.HasSuffix(size, ",") {
+			var minor []string
+			minor, rest = cutFields(rest, 1)
+			size += strings.Join(minor, "")
+		}
+		date, name := cutFields(rest, 3)
+		if len(date) < 3 || name == "" {
+			continue
+		}
+
+		file := containerFile{
+			name:     name,
+			size:     size,
+			modified: strings.Join(date, " "),
+		}
+		switch fields[0][0] {
+		case 'd':
+			file.fileType = fileTypeDir
+		case '-':
+			file.fileType = fileTypeFile
+		case 'l':
+			file.fileType = fileTypeLink
+			if i := strings.Index(name, " -> "); i >= 0 {
+				file.name, file.target = name[:i], name[i+4:]
+			}
+		default:
+			file.fileType = string(fields[0][0])
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// cutFields takes the first n fields of a line, and returns them with the rest of the line, spaces in it kept.
+func cutFields(line string, n int) ([]string, string) {
+	var fields []string
+	rest := line
+	for len(fields) < n {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			break
+		}
+		i := strings.IndexAny(rest, " \t")
+		if i < 0 {
+			fields, rest = append(fields, rest), ""
+			break
+		}
+		fields, rest = append(fields, rest[:i]), rest[i:]
+	}
+	return fields, strings.TrimLeft(rest, " \t")
+}
+
+/*
+runInContainer runs a command in a container without a tty. Stderr is returned in the error when the command fails, and
+a command the image does not provide is reported as such.
+*/
+func runInContainer(t *throwing.TableView, namespace, pod, container string, command []string, stdin io.Reader, stdout io.Writer) error {
+	stderr := &bytes.Buffer{}
+	streams := remotecommand.StreamOptions{Stdout: stdout, Stderr: stderr}
+	if stdin != nil {
+		streams.Stdin = stdin
+	}
+	if stdout == nil {
+		streams.Stdout = ioutil.Discard
+	}
+	code, err := streamExec(t, namespace, pod, container, command, streams)
+	message := strings.TrimSpace(stderr.String())
+	// the runtime fails to start a missing executable, a shell in front of it exits with 127
+	missing := code == 127 ||
+		(err != nil && (strings.Contains(err.Error(), "executable file not found") ||
+			strings.Contains(err.Error(), fmt.Sprintf("exec: %q", command[0]))))
+	switch {
+	case missing:
+		return fmt.Errorf("%s is not installed in container %s, the image has to provide it to browse and copy files", command[0], container)
+	case err != nil:
+		return err
+	case code != 0 && message != "":
+		return fmt.Errorf("%s: %s", command[0], message)
+	case code != 0:
+		return fmt.Errorf("%s exited with code %d", command[0], code)
+	}
+	return nil
+}
+
+// copyProgress counts what a copy transferred so far, total is only known for uploads.
+type copyProgress struct {
+	lock    sync.Mutex
+	bytes   int64
+	total   int64
+	files   int
+	skipped int
+}
+
+func (p *copyProgress) add(n int) {
+	p.lock.Lock()
+	p.bytes += int64(n)
+	p.lock.Unlock()
+}
+
+func (p *copyProgress) addFile(skipped bool) {
+	p.lock.Lock()
+	if skipped {
+		p.skipped++
+	} else {
+		p.files++
+	}
+	p.lock.Unlock()
+}
+
+func (p *copyProgress) String() string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	s := fmt.Sprintf("%s, %d files", formatBytes(p.bytes), p.files)
+	if p.total > 0 {
+		s = fmt.Sprintf("%s of %s (%d%%), %d files", formatBytes(p.bytes), formatBytes(p.total), p.bytes*100/p.total, p.files)
+	}
+	if p.skipped > 0 {
+		s += fmt.Sprintf(", %d skipped (links or special files)", p.skipped)
+	}
+	return s
+}
+
+// progressReader counts the bytes read through it.
+type progressReader struct {
+	r        io.Reader
+	progress *copyProgress
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.progress.add(n)
+	return n, err
+}
+
+/*
+download copies a file or directory of a container into a local directory, with `tar cf -` in the container unpacked
+locally. Links are skipped, as kubectl cp does, and entries that would land outside of the local directory, or be written
+through a link found in it, are refused.
+*/
+func (f *containerFiles) download(name, localDir string, progress *copyProgress, cancel <-chan struct{}) error {
+	reader, writer := io.Pipe()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-cancel:
+			writer.CloseWithError(errCopyCanceled)
+			reader.CloseWithError(errCopyCanceled)
+		case <-done:
+		}
+	}()
+
+	errs := make(chan error, 1)
+	go func() {
+		err := runInContainer(f.t, f.namespace, f.pod, f.container, []string{"tar", "cf", "-", "-C", f.shown, "--", name}, nil, writer)
+		writer.CloseWithError(err)
+		errs <- err
+	}()
+
+	err := untar(&progressReader{reader, progress}, localDir, progress)
+	if err == nil {
+		// the archive is padded after its end
+		_, err = io.Copy(ioutil.Discard, reader)
+	}
+	if err != nil {
+		// the remote tar is left to finish into the void rather than waited for
+		go io.Copy(ioutil.Discard, reader)
+		return err
+	}
+	return <-errs
+}
+
+func untar(r io.Reader, localDir string, progress *copyProgress) error {
+	root := filepath.Clean(localDir)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(root, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(target, root+string(os.PathSeparator)) {
+			return fmt.Errorf("%s is outside of %s", header.Name, localDir)
+		}
+		// the target itself may be a link left there before, to a file outside of the directory
+		if err := checkNoLinks(root, target); err != nil {
+			return fmt.Errorf("%s: %v", header.Name, err)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(header.Mode)|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode)&os.ModePerm)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(file, tr)
+			file.Close()
+			if err != nil {
+				return err
+			}
+			progress.addFile(false)
+		default:
+			progress.addFile(true)
+		}
+	}
+}
+
+// checkNoLinks refuses to write to a path under root if it, or one of its parents under root, is a link.
+func checkNoLinks(root, dir string) error {
+	for ; dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+		info, err := os.Lstat(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%s is a link, files are not written through links", dir)
+		}
+	}
+	return nil
+}
+
+// upload copies a local file or directory into the directory shown, packed locally and unpacked with `tar xf -`.
+func (f *containerFiles) upload(localPath string, progress *copyProgress, cancel <-chan struct{}) error {
+	total, err := localSize(localPath)
+	if err != nil {
+		return err
+	}
+	progress.lock.Lock()
+	progress.total = total
+	progress.lock.Unlock()
+
+	reader, writer := io.Pipe()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-cancel:
+			writer.CloseWithError(errCopyCanceled)
+		case <-done:
+		}
+	}()
+
+	tarErrs := make(chan error, 1)
+	go func() {
+		err := tarPath(writer, localPath, progress)
+		writer.CloseWithError(err)
+		tarErrs <- err
+	}()
+
+	err = runInContainer(f.t, f.namespace, f.pod, f.container, []string{"tar", "xf", "-", "-C", f.shown}, reader, nil)
+	// stops packing if the remote tar failed early
+	reader.CloseWithError(err)
+	if tarErr := <-tarErrs; tarErr != nil && tarErr != io.ErrClosedPipe && tarErr != err {
+		// a local error, or the cancel, explains the failure of the remote tar better
+		return tarErr
+	}
+	return err
+}
+
+func localSize(localPath string) (int64, error) {
+	var total int64
+	err := filepath.Walk(localPath, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
+
+// tarPath packs a local file or directory, under its base name.
+func tarPath(w io.Writer, localPath string, progress *copyProgress) error {
+	localPath = filepath.Clean(localPath)
+	base := filepath.Dir(localPath)
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(localPath, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		} else if !info.IsDir() && !info.Mode().IsRegular() {
+			progress.addFile(true)
+			return nil
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(base, file)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		in, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		if _, err := io.Copy(tw, &progressReader{in, progress}); err != nil {
+			return err
+		}
+		progress.addFile(false)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+/*
+runCopy shows the progress of a copy on a page of its own until it ends, c cancels it. The page can be left with Esc
+while the copy goes on, the browser is refreshed once it is done.
+*/
+func runCopy(t *throwing.TableView, title string, progress *copyProgress, transfer func(cancel <-chan struct{}) error) {
+	cancel := make(chan struct{})
+	var once sync.Once
+	box := showText(t, "copy", title+" [c] cancel", progress.String())
+	box.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Rune() == 'c' {
+			once.Do(func() {
+				close(cancel)
+			})
+			return nil
+		}
+		return event
+	})
+
+	app := t.GetApplication()
+	finished := make(chan error, 1)
+	go func() {
+		finished <- transfer(cancel)
+	}()
+	go func() {
+		ticker := time.NewTicker(copyProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				app.QueueUpdateDraw(func() {
+					box.SetText(progress.String())
+				})
+			case err := <-finished:
+				app.QueueUpdateDraw(func() {
+					if err != nil {
+						box.SetTitle(title + " failed")
+						box.SetText(fmt.Sprintf("%s\n\n[red]%s[white]", progress, tview.Escape(err.Error())))
+						return
+					}
+					box.SetTitle(title + " done")
+					box.SetText(progress.String())
+					t.RefreshManual()
+				})
+				return
+			}
+		}
+	}()
+}
+
+// copyDialog asks where to copy the selected entry to, or what to copy into the directory shown.
+func copyDialog(t *throwing.TableView, f *containerFiles, upload bool, name string) {
+	localPath, _ := os.Getwd()
+	title := fmt.Sprintf("download - %s to local directory", path.Join(f.shown, name))
+	if upload {
+		localPath = ""
+		title = fmt.Sprintf("upload - local file or directory to %s", f.shown)
+	}
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle(title)
+	form.AddInputField("Local path", localPath, 50, nil, func(text string) {
+		localPath = strings.TrimSpace(text)
+	})
+	form.AddButton("copy", func() {
+		if strings.HasPrefix(localPath, "~/") {
+			localPath = filepath.Join(os.Getenv("HOME"), localPath[2:])
+		}
+		if localPath == "" {
+			t.UpdateStatus("a local path is required", true)
+			return
+		}
+		t.BackPage()
+		progress := &copyProgress{}
+		if upload {
+			runCopy(t, fmt.Sprintf("upload - %s to %s:%s", localPath, f.pod, f.shown), progress, func(cancel <-chan struct{}) error {
+				return f.upload(localPath, progress, cancel)
+			})
+			return
+		}
+		runCopy(t, fmt.Sprintf("download - %s:%s to %s", f.pod, path.Join(f.shown, name), localPath), progress, func(cancel <-chan struct{}) error {
+			return f.download(name, localPath, progress, cancel)
+		})
+	})
+	form.AddButton("Cancel", func() {
+		t.BackPage()
+	})
+	form.SetCancelFunc(func() {
+		t.BackPage()
+	})
+	t.InsertDialog("copy", t.GetCurrentPrimitive(), form)
+}
+
+func filesEventHandler(f *containerFiles) throwing.EventHandler {
+	return func(t *throwing.TableView) func(event *tcell.EventKey) *tcell.EventKey {
+		return func(event *tcell.EventKey) *tcell.EventKey {
+			table := t.GetTable()
+			row, _ := table.GetSelection()
+			name := table.GetCell(row, 0).Text
+			e, selected := f.entry(name)
+			switch event.Key() {
+			case tcell.KeyEnter:
+				switch {
+				case name == "..":
+					f.open(t, path.Dir(f.shown))
+				case selected && e.fileType != fileTypeFile:
+					f.open(t, path.Join(f.shown, name))
+				case selected:
+					t.UpdateStatus(fmt.Sprintf("%s is a file, download it with d", name), false)
+				}
+			case tcell.KeyEscape:
+				t.BackPage()
+			case tcell.KeyRune:
+				switch event.Rune() {
+				case 'd':
+					if !selected {
+						return event
+					}
+					copyDialog(t, f, false, name)
+				case 'u':
+					copyDialog(t, f, true, "")
+				case 'r':
+					t.RefreshManual()
+				case 'q':
+					t.RootPage()
+				case '/':
+					t.ShowSearch()
+				}
+			}
+			return event
+		}
+	}
+}
+
+// browseFiles picks a container of the pod of the current row and opens its file browser, to copy files from and to it.
+func browseFiles(t *throwing.TableView) {
+	if t.GetResourceKind() != "pods" {
+		t.UpdateStatus("only the containers of pods have files", true)
+		return
+	}
+
+	namespace, name := getNamespaceAndName(t)
+	pod, err := t.GetClientSet().CoreV1().Pods(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+
+	var containers []string
+	for _, c := range pod.Spec.Containers {
+		containers = append(containers, c.Name)
+	}
+	if len(containers) == 0 {
+		t.UpdateStatus(fmt.Sprintf("pod %s has no containers", name), true)
+		return
+	}
+	index, dir := 0, "/"
+	if wd := pod.Spec.Containers[0].WorkingDir; wd != "" {
+		dir = wd
+	}
+
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle(fmt.Sprintf("files - (%s)", name))
+	form.AddDropDown("Container", containers, 0, func(option string, optionIndex int) {
+		index = optionIndex
+	})
+	form.AddInputField("Directory", dir, 40, nil, func(text string) {
+		dir = strings.TrimSpace(text)
+	})
+	form.AddButton("browse", func() {
+		if !path.IsAbs(dir) {
+			dir = "/" + dir
+		}
+		f := &containerFiles{
+			t:         t,
+			namespace: namespace,
+			pod:       name,
+			container: containers[index],
+			dir:       path.Clean(dir),
+		}
+		if err := f.Refresh(); err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		t.BackPage()
+		kind := types.ResourceKind{
+			Title: f.title(),
+			Kind:  filesKind,
+		}
+		page := fmt.Sprintf("%s@%s/%s/%s", filesKind, namespace, name, f.container)
+		newtable := t.NewNestTableView(kind, f, filesActions, nil, filesEventHandler(f))
+		t.SetTableView(page, newtable)
+		t.SwitchPage(page, newtable)
+	})
+	form.AddButton("Cancel", func() {
+		t.BackPage()
+	})
+	form.SetCancelFunc(func() {
+		t.BackPage()
+	})
+	t.InsertDialog("files", t.GetCurrentPrimitive(), form)
+}
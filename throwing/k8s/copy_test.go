@@ -0,0 +1,206 @@
+package k8s
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseListing(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []containerFile
+	}{
+		{
+			name:   "gnu",
+			output: "total 8\ndrwxr-xr-x 2 root root 4096 Jan  1  2020 bin\n-rw-r--r-- 1 root root 5 Mar  3 10:00 a.txt\n",
+			want: []containerFile{
+				{name: "bin", fileType: fileTypeDir, size: "4096", modified: "Jan 1 2020"},
+				{name: "a.txt", fileType: fileTypeFile, size: "5", modified: "Mar 3 10:00"},
+			},
+		},
+		{
+			name:   "busybox link",
+			output: "lrwxrwxrwx    1 root     root             7 Jan  1 12:00 lib -> usr/lib\n",
+			want: []containerFile{
+				{name: "lib", fileType: fileTypeLink, size: "7", modified: "Jan 1 12:00", target: "usr/lib"},
+			},
+		},
+		{
+			name:   "device",
+			output: "crw-rw-rw- 1 root root 1, 3 Jan  1 12:00 null\n",
+			want: []containerFile{
+				{name: "null", fileType: "c", size: "1,3", modified: "Jan 1 12:00"},
+			},
+		},
+		{
+			name:   "spaces in name",
+			output: "-rw-r--r-- 1 a b 5 Mar  3 10:00 my  file.txt\n",
+			want: []containerFile{
+				{name: "my  file.txt", fileType: fileTypeFile, size: "5", modified: "Mar 3 10:00"},
+			},
+		},
+		{
+			name:   "short line",
+			output: "ls: cannot access\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := parseListing(tt.output); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseListing() = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	content  string
+}
+
+func makeTar(t *testing.T, entries []tarEntry) *bytes.Buffer {
+	b := &bytes.Buffer{}
+	tw := tar.NewWriter(b)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Typeflag: e.typeflag, Linkname: e.linkname, Mode: 0644, Size: int64(len(e.content))}
+		if e.typeflag == tar.TypeDir {
+			header.Mode = 0755
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestUntar(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []tarEntry
+		// files are the files expected under the local directory, with their content
+		files   map[string]string
+		skipped int
+		wantErr bool
+	}{
+		{
+			name: "files and directories",
+			entries: []tarEntry{
+				{name: "d/", typeflag: tar.TypeDir},
+				{name: "d/a.txt", typeflag: tar.TypeReg, content: "hello"},
+				{name: "d/e/b.txt", typeflag: tar.TypeReg, content: "world"},
+			},
+			files: map[string]string{"d/a.txt": "hello", "d/e/b.txt": "world"},
+		},
+		{
+			name: "links are skipped",
+			entries: []tarEntry{
+				{name: "d/a.txt", typeflag: tar.TypeReg, content: "hello"},
+				{name: "d/ln", typeflag: tar.TypeSymlink, linkname: "a.txt"},
+				{name: "d/abs", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"},
+			},
+			files:   map[string]string{"d/a.txt": "hello"},
+			skipped: 2,
+		},
+		{
+			name: "parent path",
+			entries: []tarEntry{
+				{name: "../evil", typeflag: tar.TypeReg, content: "x"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "chain of relative links",
+			entries: []tarEntry{
+				{name: "a/b/c/", typeflag: tar.TypeDir},
+				{name: "a/b/c/l1", typeflag: tar.TypeSymlink, linkname: ".."},
+				{name: "a/b/c/l1/l2", typeflag: tar.TypeSymlink, linkname: ".."},
+				{name: "a/b/c/l1/l2/l3", typeflag: tar.TypeSymlink, linkname: ".."},
+				{name: "a/b/c/l1/l2/l3/l4", typeflag: tar.TypeSymlink, linkname: ".."},
+				{name: "a/b/c/l1/l2/l3/l4/evil", typeflag: tar.TypeReg, content: "x"},
+			},
+			// without the links, the file lands where its name says, inside the directory
+			files:   map[string]string{"a/b/c/l1/l2/l3/l4/evil": "x"},
+			skipped: 4,
+		},
+	}
+	for _, tt := range tests {
+		parent, err := ioutil.TempDir("", "axe-untar")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(parent)
+		dir := filepath.Join(parent, "download")
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		progress := &copyProgress{}
+		err = untar(makeTar(t, tt.entries), dir, progress)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: untar() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(parent, "evil")); err == nil {
+			t.Errorf("%s: untar() wrote outside of the local directory", tt.name)
+		}
+		for file, content := range tt.files {
+			data, err := ioutil.ReadFile(filepath.Join(dir, file))
+			if err != nil || string(data) != content {
+				t.Errorf("%s: %s = %q, %v, want %q", tt.name, file, data, err, content)
+			}
+		}
+		if progress.skipped != tt.skipped {
+			t.Errorf("%s: skipped %d, want %d", tt.name, progress.skipped, tt.skipped)
+		}
+	}
+}
+
+func TestUntarThroughExistingLink(t *testing.T) {
+	tests := []struct {
+		name string
+		// link is made in the local directory before, to the directory above it
+		link  string
+		entry string
+	}{
+		{name: "link to a directory", link: "up", entry: "up/evil"},
+		{name: "link at the file", link: "evil", entry: "evil"},
+	}
+	for _, tt := range tests {
+		parent, err := ioutil.TempDir("", "axe-untar")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(parent)
+		dir := filepath.Join(parent, "download")
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		target := parent
+		if tt.link == tt.entry {
+			target = filepath.Join(parent, "evil")
+		}
+		if err := os.Symlink(target, filepath.Join(dir, tt.link)); err != nil {
+			t.Fatal(err)
+		}
+
+		entries := []tarEntry{{name: tt.entry, typeflag: tar.TypeReg, content: "x"}}
+		if err := untar(makeTar(t, entries), dir, &copyProgress{}); err == nil {
+			t.Errorf("%s: untar() wrote through a link of the local directory", tt.name)
+		}
+		if _, err := os.Stat(filepath.Join(parent, "evil")); err == nil {
+			t.Errorf("%s: untar() wrote outside of the local directory", tt.name)
+		}
+	}
+}
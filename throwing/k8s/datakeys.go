@@ -0,0 +1,559 @@
+package k8s
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell"
+	"github.com/rancher/axe/throwing"
+	"github.com/rancher/axe/throwing/datafeeder"
+	"github.com/rancher/axe/throwing/types"
+	"github.com/rivo/tview"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	dataKind = "data"
+)
+
+var (
+	dataActions = []types.Action{
+		{
+			Name:        "reveal",
+			Shortcut:    "v",
+			Description: "reveal or hide the value of a key",
+		},
+		{
+			Name:        "copy",
+			Shortcut:    "y",
+			Description: "copy the value of a key to the clipboard",
+		},
+		{
+			Name:        "edit",
+			Shortcut:    "e",
+			Description: "edit the value of a key",
+		},
+		{
+			Name:        "add",
+			Shortcut:    "a",
+			Description: "add a key",
+		},
+		{
+			Name:        "remove",
+			Shortcut:    "d",
+			Description: "remove a key",
+		},
+	}
+)
+
+/*
+dataView is the DataSource of the page of the keys of a config map or secret. Values are decoded, those of secrets are
+masked until revealed one key at a time, and certificates are parsed to show when they expire. Keys are edited with
+merge patches of the key alone, so that what others change in the meantime is kept.
+*/
+type dataView struct {
+	clientset  *kubernetes.Clientset
+	resource   string
+	namespace  string
+	name       string
+	secretType string
+	data       map[string][]byte
+	// binary are the keys of the binaryData of a config map
+	binary   map[string]bool
+	revealed map[string]bool
+}
+
+func (d *dataView) Header() datafeeder.Row {
+	return datafeeder.Row{"KEY", "BYTES", "VALUE", "EXPIRES"}
+}
+
+func (d *dataView) Data() []datafeeder.Row {
+	var rows []datafeeder.Row
+	for _, key := range d.keys() {
+		value := d.data[key]
+		shown := secretMask
+		if !d.masked(key) {
+			shown = valuePreview(value)
+		}
+		expires := "-"
+		if certs := parseCertificates(value); len(certs) > 0 {
+			expires = certificateExpiry(certs)
+		}
+		rows = append(rows, datafeeder.Row{key, fmt.Sprint(len(value)), shown, expires})
+	}
+	return rows
+}
+
+func (d *dataView) Refresh() error {
+	d.data, d.binary = map[string][]byte{}, map[string]bool{}
+	if d.secret() {
+		secret, err := d.clientset.CoreV1().Secrets(d.namespace).Get(d.name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		d.secretType = string(secret.Type)
+		for key, value := range secret.Data {
+			d.data[key] = value
+		}
+		return nil
+	}
+
+	configMap, err := d.clientset.CoreV1().ConfigMaps(d.namespace).Get(d.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	for key, value := range configMap.Data {
+		d.data[key] = []byte(value)
+	}
+	for key, value := range configMap.BinaryData {
+		d.data[key] = value
+		d.binary[key] = true
+	}
+	return nil
+}
+
+func (d *dataView) secret() bool {
+	return d.resource == "secrets"
+}
+
+func (d *dataView) masked(key string) bool {
+	return d.secret() && !d.revealed[key]
+}
+
+func (d *dataView) keys() []string {
+	var keys []string
+	for key := range d.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// details returns the certificates of a value, or the value itself.
+func (d *dataView) details(key string) string {
+	value := d.data[key]
+	if certs := parseCertificates(value); len(certs) > 0 {
+		return certificateDetails(certs)
+	}
+	if d.masked(key) {
+		return fmt.Sprintf("%s\n\n[v] reveal", secretMask)
+	}
+	if !printable(value) {
+		return base64.StdEncoding.EncodeToString(value)
+	}
+	return tview.Escape(string(value))
+}
+
+/*
+patch sets or removes one key with a merge patch. Values of secrets are encoded in base64, those of config maps go to
+data if they are text, or to binaryData, encoded, if they are not.
+*/
+func (d *dataView) patch(key string, value []byte, remove bool) error {
+	data, binaryData := map[string]interface{}{}, map[string]interface{}{}
+	_, exists := d.data[key]
+	switch {
+	case remove && d.binary[key]:
+		binaryData[key] = nil
+	case remove:
+		data[key] = nil
+	case d.secret():
+		data[key] = base64.StdEncoding.EncodeToString(value)
+	case utf8.Valid(value):
+		data[key] = string(value)
+		if d.binary[key] {
+			binaryData[key] = nil
+		}
+	default:
+		binaryData[key] = base64.StdEncoding.EncodeToString(value)
+		if exists && !d.binary[key] {
+			data[key] = nil
+		}
+	}
+	fields := map[string]interface{}{}
+	if len(data) > 0 {
+		fields["data"] = data
+	}
+	if len(binaryData) > 0 {
+		fields["binaryData"] = binaryData
+	}
+	patch, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	if d.secret() {
+		_, err = d.clientset.CoreV1().Secrets(d.namespace).Patch(d.name, k8stypes.MergePatchType, patch)
+		return err
+	}
+	_, err = d.clientset.CoreV1().ConfigMaps(d.namespace).Patch(d.name, k8stypes.MergePatchType, patch)
+	return err
+}
+
+/*
+editKey opens the value of a key in the editor of the user. Text is edited as is, other values as base64, and the
+newline editors add at the end is dropped if the value had none.
+*/
+func (d *dataView) editKey(t *throwing.TableView, key string) {
+	value := d.data[key]
+	text, encoded := string(value), !printable(value)
+	if encoded {
+		text = base64.StdEncoding.EncodeToString(value) + "\n"
+	}
+	edited, err := editText(t, key, text)
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	if !strings.HasSuffix(text, "\n") {
+		edited = strings.TrimSuffix(edited, "\n")
+	}
+	if edited == text {
+		t.UpdateStatus(fmt.Sprintf("%s unchanged", key), false)
+		return
+	}
+	updated := []byte(edited)
+	if encoded {
+		if updated, err = base64.StdEncoding.DecodeString(strings.TrimSpace(edited)); err != nil {
+			t.UpdateStatus(fmt.Sprintf("%s is not valid base64: %v", key, err), true)
+			return
+		}
+	}
+	d.update(t, key, updated, false)
+}
+
+func (d *dataView) update(t *throwing.TableView, key string, value []byte, remove bool) {
+	if err := d.patch(key, value, remove); err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	t.RefreshManual()
+	verb := "updated"
+	if remove {
+		verb = "removed"
+	}
+	t.UpdateStatus(fmt.Sprintf("%s %s", key, verb), false)
+}
+
+/*
+addKeyDialog adds a key with the content of a file, or with a value written in the editor if no file is given. As when a
+key is edited, the newline the editor adds at the end is dropped.
+*/
+func (d *dataView) addKeyDialog(t *throwing.TableView) {
+	key, path := "", ""
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle(fmt.Sprintf("add key - (%s)", d.name))
+	form.AddInputField("Key", "", 40, nil, func(text string) {
+		key = strings.TrimSpace(text)
+	})
+	form.AddInputField("From file", "", 40, nil, func(text string) {
+		path = strings.TrimSpace(text)
+	})
+	form.AddButton("add", func() {
+		if path != "" && key == "" {
+			key = filepath.Base(path)
+		}
+		if err := validateDataKey(key); err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		if _, ok := d.data[key]; ok {
+			t.UpdateStatus(fmt.Sprintf("%s exists already, edit it with e", key), true)
+			return
+		}
+		if path != "" {
+			value, err := readDataFile(path)
+			if err != nil {
+				t.UpdateStatus(err.Error(), true)
+				return
+			}
+			t.BackPage()
+			d.update(t, key, value, false)
+			return
+		}
+		t.BackPage()
+		text, err := editText(t, key, "")
+		if err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		d.update(t, key, []byte(strings.TrimSuffix(text, "\n")), false)
+	})
+	form.AddButton("Cancel", func() {
+		t.BackPage()
+	})
+	form.SetCancelFunc(func() {
+		t.BackPage()
+	})
+	t.InsertDialog("add-key", t.GetCurrentPrimitive(), form)
+}
+
+func validateDataKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("a key is required")
+	}
+	if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+		return fmt.Errorf("invalid key %q: %s", key, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func readDataFile(path string) ([]byte, error) {
+	if strings.HasPrefix(path, "~/") {
+		path = filepath.Join(os.Getenv("HOME"), path[2:])
+	}
+	return ioutil.ReadFile(path)
+}
+
+func dataEventHandler(d *dataView) throwing.EventHandler {
+	return func(t *throwing.TableView) func(event *tcell.EventKey) *tcell.EventKey {
+		return func(event *tcell.EventKey) *tcell.EventKey {
+			table := t.GetTable()
+			row, _ := table.GetSelection()
+			key := table.GetCell(row, 0).Text
+			_, selected := d.data[key]
+			switch event.Key() {
+			case tcell.KeyEnter:
+				if !selected {
+					return event
+				}
+				box := showText(t, "value", fmt.Sprintf("%s - (%s) %s", d.resource, d.name, key), d.details(key))
+				box.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+					if event.Rune() == 'v' {
+						d.revealed[key] = !d.revealed[key]
+						box.SetText(d.details(key))
+						return nil
+					}
+					return event
+				})
+			case tcell.KeyEscape:
+				t.BackPage()
+			case tcell.KeyRune:
+				switch event.Rune() {
+				case 'v':
+					if !selected {
+						return event
+					}
+					d.revealed[key] = !d.revealed[key]
+					t.RefreshManual()
+				case 'V':
+					// reveal all unless all are revealed already, then hide all
+					reveal := false
+					for k := range d.data {
+						reveal = reveal || !d.revealed[k]
+					}
+					for k := range d.data {
+						d.revealed[k] = reveal
+					}
+					t.RefreshManual()
+				case 'y':
+					if !selected {
+						return event
+					}
+					if err := copyToClipboard(d.data[key]); err != nil {
+						t.UpdateStatus(err.Error(), true)
+						return event
+					}
+					t.UpdateStatus(fmt.Sprintf("%s copied to the clipboard", key), false)
+				case 'e':
+					if selected {
+						d.editKey(t, key)
+					}
+				case 'a':
+					d.addKeyDialog(t)
+				case 'd':
+					if !selected {
+						return event
+					}
+					confirm(t, "remove", fmt.Sprintf("Do you want to remove %s from %s?", key, d.name), func() {
+						// close the dialog back to the keys page
+						t.BackPage()
+						d.update(t, key, nil, true)
+					})
+				case 'r':
+					t.RefreshManual()
+				case 'q':
+					t.RootPage()
+				case '/':
+					t.ShowSearch()
+				}
+			}
+			return event
+		}
+	}
+}
+
+// viewData opens the page of the keys of the config map or secret of the current row.
+func viewData(t *throwing.TableView) {
+	resource := t.GetResourceKind()
+	if resource != "secrets" && resource != "configmaps" {
+		t.UpdateStatus("only config maps and secrets have keys", true)
+		return
+	}
+	namespace, name := getNamespaceAndName(t)
+	page := fmt.Sprintf("%s@%s/%s/%s", dataKind, resource, namespace, name)
+	d := &dataView{
+		clientset: t.GetClientSet(),
+		resource:  resource,
+		namespace: namespace,
+		name:      name,
+		revealed:  map[string]bool{},
+	}
+	if err := d.Refresh(); err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	title := fmt.Sprintf("configmap - %s [y] copy [e] edit [a] add [d] remove [Enter] value", name)
+	if d.secret() {
+		title = fmt.Sprintf("secret - %s (%s) [v] reveal [V] reveal all [y] copy [e] edit [a] add [d] remove [Enter] details", name, d.secretType)
+	}
+	kind := types.ResourceKind{
+		Title: title,
+		Kind:  dataKind,
+	}
+	// a new page every time, so that values are masked again
+	newtable := t.NewNestTableView(kind, d, dataActions, nil, dataEventHandler(d))
+	t.SetTableView(page, newtable)
+	t.SwitchPage(page, newtable)
+}
+
+/*
+parseDataEntry parses an entry of the create dialog: `key=value` for a literal, `@path` or `key=@path` for a file, the
+key of which is the name of the file by default.
+*/
+func parseDataEntry(text string) (string, []byte, error) {
+	key, value := "", text
+	if i := strings.Index(text, "="); i >= 0 && !strings.HasPrefix(text, "@") {
+		key, value = text[:i], text[i+1:]
+	} else if !strings.HasPrefix(text, "@") {
+		return "", nil, fmt.Errorf("%q is neither key=value nor @file", text)
+	}
+	if strings.HasPrefix(value, "@") {
+		path := value[1:]
+		if key == "" {
+			key = filepath.Base(path)
+		}
+		data, err := readDataFile(path)
+		if err != nil {
+			return "", nil, err
+		}
+		return key, data, validateDataKey(key)
+	}
+	return key, []byte(value), validateDataKey(key)
+}
+
+/*
+createDataDialog creates a config map or a secret from literals and files, one `key=value`, `@path` or `key=@path` entry
+per row, like kubectl create with --from-literal and --from-file.
+*/
+func createDataDialog(t *throwing.TableView) {
+	kinds := []string{"ConfigMap", "Secret"}
+	selected := 0
+	if t.GetResourceKind() == "secrets" {
+		selected = 1
+	}
+	name, namespace, secretType := "", currentNamespace(t), string(corev1.SecretTypeOpaque)
+
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle("create - key=value, @file or key=@file per row")
+	form.AddDropDown("Kind", kinds, selected, func(option string, index int) {
+		selected = index
+	})
+	form.AddInputField("Name", "", 30, nil, func(text string) {
+		name = strings.TrimSpace(text)
+	})
+	form.AddInputField("Namespace", namespace, 30, nil, func(text string) {
+		namespace = strings.TrimSpace(text)
+	})
+	form.AddInputField("Secret type", secretType, 30, nil, func(text string) {
+		secretType = strings.TrimSpace(text)
+	})
+	var rows []*tview.InputField
+	addRow := func() {
+		row := tview.NewInputField().SetFieldWidth(metadataFieldWidth)
+		rows = append(rows, row)
+		form.AddFormItem(row)
+	}
+	for i := 0; i < metadataNewRows; i++ {
+		addRow()
+	}
+	form.AddButton("create", func() {
+		if name == "" {
+			t.UpdateStatus("a name is required", true)
+			return
+		}
+		data := map[string][]byte{}
+		for _, row := range rows {
+			text := strings.TrimSpace(row.GetText())
+			if text == "" {
+				continue
+			}
+			key, value, err := parseDataEntry(text)
+			if err != nil {
+				t.UpdateStatus(err.Error(), true)
+				return
+			}
+			if _, ok := data[key]; ok {
+				t.UpdateStatus(fmt.Sprintf("key %s is given twice", key), true)
+				return
+			}
+			data[key] = value
+		}
+		if err := createData(t.GetClientSet(), kinds[selected], namespace, name, secretType, data); err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		t.BackPage()
+		t.RefreshManual()
+		t.UpdateStatus(fmt.Sprintf("%s %s created with %d keys", kinds[selected], name, len(data)), false)
+	})
+	form.AddButton("add", func() {
+		addRow()
+	})
+	form.AddButton("Cancel", func() {
+		t.BackPage()
+	})
+	form.SetCancelFunc(func() {
+		t.BackPage()
+	})
+	t.InsertDialog("create", t.GetCurrentPrimitive(), form)
+}
+
+// createData creates a config map, where values that are not text go to binaryData, or a secret.
+func createData(clientset *kubernetes.Clientset, kind, namespace, name, secretType string, data map[string][]byte) error {
+	meta := metav1.ObjectMeta{Namespace: namespace, Name: name}
+	if kind == "Secret" {
+		_, err := clientset.CoreV1().Secrets(namespace).Create(&corev1.Secret{
+			ObjectMeta: meta,
+			Type:       corev1.SecretType(secretType),
+			Data:       data,
+		})
+		return err
+	}
+
+	configMap := &corev1.ConfigMap{ObjectMeta: meta}
+	for key, value := range data {
+		if utf8.Valid(value) {
+			if configMap.Data == nil {
+				configMap.Data = map[string]string{}
+			}
+			configMap.Data[key] = string(value)
+			continue
+		}
+		if configMap.BinaryData == nil {
+			configMap.BinaryData = map[string][]byte{}
+		}
+		configMap.BinaryData[key] = value
+	}
+	_, err := clientset.CoreV1().ConfigMaps(namespace).Create(configMap)
+	return err
+}
@@ -0,0 +1,162 @@
+package k8s
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rancher/axe/throwing/datafeeder"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestDataViewRows(t *testing.T) {
+	data := map[string][]byte{"b": []byte("two"), "a": []byte("one")}
+	tests := []struct {
+		resource string
+		revealed map[string]bool
+		want     []datafeeder.Row
+	}{
+		{
+			resource: "secrets",
+			revealed: map[string]bool{"b": true},
+			want:     []datafeeder.Row{{"a", "3", secretMask, "-"}, {"b", "3", "two", "-"}},
+		},
+		{
+			resource: "configmaps",
+			want:     []datafeeder.Row{{"a", "3", "one", "-"}, {"b", "3", "two", "-"}},
+		},
+	}
+	for _, tt := range tests {
+		d := &dataView{resource: tt.resource, data: data, revealed: tt.revealed}
+		if got := d.Data(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Data() = %v, want %v", tt.resource, got, tt.want)
+		}
+	}
+}
+
+func TestParseDataEntry(t *testing.T) {
+	dir, err := ioutil.TempDir("", "axe-data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "app.conf")
+	if err := ioutil.WriteFile(file, []byte("port=80\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text      string
+		wantKey   string
+		wantValue string
+		wantErr   bool
+	}{
+		{text: "user=admin", wantKey: "user", wantValue: "admin"},
+		{text: "url=http://a?b=c", wantKey: "url", wantValue: "http://a?b=c"},
+		{text: "empty=", wantKey: "empty", wantValue: ""},
+		{text: "@" + file, wantKey: "app.conf", wantValue: "port=80\n"},
+		{text: "config=@" + file, wantKey: "config", wantValue: "port=80\n"},
+		{text: "config=@" + filepath.Join(dir, "missing"), wantErr: true},
+		{text: "no separator", wantErr: true},
+		{text: "=value", wantErr: true},
+		{text: "bad/key=value", wantErr: true},
+	}
+	for _, tt := range tests {
+		key, value, err := parseDataEntry(tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDataEntry(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (key != tt.wantKey || string(value) != tt.wantValue) {
+			t.Errorf("parseDataEntry(%q) = %q, %q, want %q, %q", tt.text, key, value, tt.wantKey, tt.wantValue)
+		}
+	}
+}
+
+func TestDataViewPatch(t *testing.T) {
+	var path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		path, body = r.URL.Path, string(data)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		resource string
+		key      string
+		value    string
+		remove   bool
+		wantPath string
+		wantBody string
+	}{
+		{
+			name:     "secret value encoded",
+			resource: "secrets",
+			key:      "password",
+			value:    "hunter2",
+			wantPath: "/api/v1/namespaces/default/secrets/web",
+			wantBody: `{"data":{"password":"aHVudGVyMg=="}}`,
+		},
+		{
+			name:     "text to data",
+			resource: "configmaps",
+			key:      "conf",
+			value:    "port=80",
+			wantPath: "/api/v1/namespaces/default/configmaps/web",
+			wantBody: `{"data":{"conf":"port=80"}}`,
+		},
+		{
+			name:     "binary moves from data to binaryData",
+			resource: "configmaps",
+			key:      "text",
+			value:    "\xff",
+			wantPath: "/api/v1/namespaces/default/configmaps/web",
+			wantBody: `{"binaryData":{"text":"/w=="},"data":{"text":null}}`,
+		},
+		{
+			name:     "text moves from binaryData to data",
+			resource: "configmaps",
+			key:      "blob",
+			value:    "text",
+			wantPath: "/api/v1/namespaces/default/configmaps/web",
+			wantBody: `{"binaryData":{"blob":null},"data":{"blob":"text"}}`,
+		},
+		{
+			name:     "binary key removed",
+			resource: "configmaps",
+			key:      "blob",
+			remove:   true,
+			wantPath: "/api/v1/namespaces/default/configmaps/web",
+			wantBody: `{"binaryData":{"blob":null}}`,
+		},
+	}
+	for _, tt := range tests {
+		d := &dataView{
+			clientset: clientset,
+			resource:  tt.resource,
+			namespace: "default",
+			name:      "web",
+			data:      map[string][]byte{"text": []byte("a"), "blob": {0xff}},
+			binary:    map[string]bool{"blob": true},
+		}
+		if err := d.patch(tt.key, []byte(tt.value), tt.remove); err != nil {
+			t.Errorf("%s: patch() error = %v", tt.name, err)
+			continue
+		}
+		if path != tt.wantPath || body != tt.wantBody {
+			t.Errorf("%s: patch() sent %s %s, want %s %s", tt.name, path, body, tt.wantPath, tt.wantBody)
+		}
+	}
+}
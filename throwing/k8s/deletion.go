@@ -0,0 +1,252 @@
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rancher/axe/throwing"
+	"github.com/rivo/tview"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	defaultPropagation = "Default"
+
+	removeFinalizersPatch = `{"metadata":{"finalizers":null}}`
+)
+
+var (
+	propagationPolicies = []string{
+		defaultPropagation,
+		string(metav1.DeletePropagationBackground),
+		string(metav1.DeletePropagationForeground),
+		string(metav1.DeletePropagationOrphan),
+	}
+)
+
+/*
+deleteDialog asks how to delete the selected objects: the grace period, how owned objects are deleted, and whether to
+force it. Objects stuck in Terminating can have their finalizers removed instead. What is shown in the dialog is read in
+the background, the pod specs the objects may be used by with one list for all of them.
+*/
+func deleteDialog(t *throwing.TableView, objects []objectRef) {
+	if len(objects) == 0 {
+		return
+	}
+	r, err := lookupResource(t.GetClientSet(), t.GetResourceKind())
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+
+	app := t.GetApplication()
+	shown := t.GetCurrentPrimitive()
+	go func() {
+		terminating, warning, err := deleteChecks(t.GetClientSet(), r, t.GetResourceKind(), objects)
+		app.QueueUpdateDraw(func() {
+			// the dialog is for the page delete was asked on
+			if t.GetCurrentPrimitive() != shown {
+				return
+			}
+			if err != nil {
+				t.UpdateStatus(err.Error(), true)
+				return
+			}
+			showDeleteDialog(t, r, objects, terminating, warning)
+		})
+	}()
+}
+
+// deleteChecks returns the objects stuck in Terminating, and a warning if the objects are in use.
+func deleteChecks(clientset *kubernetes.Clientset, r apiResource, kind string, objects []objectRef) ([]*unstructured.Unstructured, string, error) {
+	var terminating []*unstructured.Unstructured
+	for _, o := range objects {
+		client, err := resourceClient(r, o.namespace)
+		if err != nil {
+			return nil, "", err
+		}
+		if obj, err := client.Get(o.name, metav1.GetOptions{}); err == nil && obj.GetDeletionTimestamp() != nil {
+			terminating = append(terminating, obj)
+		}
+	}
+	if !usedByKinds[kind] {
+		return terminating, "", nil
+	}
+
+	// the pod specs of the namespace of the objects, or of all namespaces if they are in several
+	namespace := objects[0].namespace
+	for _, o := range objects {
+		if o.namespace != namespace {
+			namespace = ""
+		}
+	}
+	specs, err := listPodSpecs(clientset, namespace)
+	if err != nil {
+		return nil, "", err
+	}
+	var warning string
+	inUse := 0
+	for _, o := range objects {
+		if usages := usagesOf(specs, kind, o.namespace, o.name); len(usages) > 0 {
+			inUse++
+			warning = fmt.Sprintf("it is in use by %s", usageSummary(usages))
+		}
+	}
+	if len(objects) > 1 && inUse > 0 {
+		warning = fmt.Sprintf("%d of them are in use", inUse)
+	}
+	return terminating, warning, nil
+}
+
+func showDeleteDialog(t *throwing.TableView, r apiResource, objects []objectRef, terminating []*unstructured.Unstructured, warning string) {
+	text := fmt.Sprintf("Delete %s?", describeObjects(t, objects))
+	if warning != "" {
+		text += "\n\nWarning: " + warning
+	}
+	if r.kind == "Secret" {
+		if journalSecrets {
+			text += fmt.Sprintf("\n\nSecrets are kept in plaintext in %s", journalDir())
+		} else {
+			text += "\n\nThe values of secrets are not kept in the recently deleted page, see --journal-secrets"
+		}
+	}
+	switch {
+	case len(terminating) > 0 && len(objects) == 1:
+		text += "\n\nIt is stuck in Terminating, its finalizers can be removed"
+	case len(terminating) > 0:
+		text += fmt.Sprintf("\n\n%d of them are stuck in Terminating, their finalizers can be removed", len(terminating))
+	}
+
+	grace, propagation, force := "", defaultPropagation, false
+	form := tview.NewForm()
+	form.AddInputField("Grace period (s)", "", 10, tview.InputFieldInteger, func(text string) {
+		grace = text
+	})
+	form.AddDropDown("Propagation", propagationPolicies, 0, func(option string, index int) {
+		propagation = option
+	})
+	form.AddCheckbox("Force", false, func(checked bool) {
+		force = checked
+	})
+	form.AddButton("delete", func() {
+		options, err := deleteOptions(grace, propagation, force)
+		if err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		applyToObjects(t, "delete", objects, func(o objectRef) error {
+			return deleteObject(t.GetClientSet(), t.GetResourceKind(), o.namespace, o.name, options)
+		})
+	})
+	if len(terminating) > 0 {
+		form.AddButton("remove finalizers", func() {
+			removeFinalizers(t, r, terminating)
+		})
+	}
+	form.AddButton("Cancel", func() {
+		t.BackPage()
+	})
+	form.SetCancelFunc(func() {
+		t.BackPage()
+	})
+
+	message := tview.NewTextView().SetWrap(true).SetWordWrap(true).SetText(text)
+	layout := tview.NewFlex().SetDirection(tview.FlexRow)
+	layout.SetBorder(true).SetTitle("delete")
+	layout.AddItem(message, 0, 1, false)
+	layout.AddItem(form, 9, 0, true)
+	t.InsertDialog("delete", t.GetCurrentPrimitive(), layout)
+}
+
+// deleteOptions builds the options of the dialog, forcing a deletion is deleting without a grace period as kubectl does.
+func deleteOptions(grace, propagation string, force bool) (*metav1.DeleteOptions, error) {
+	options := &metav1.DeleteOptions{}
+	if grace != "" {
+		seconds, err := strconv.ParseInt(grace, 10, 64)
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("invalid grace period %q", grace)
+		}
+		if seconds == 0 && !force {
+			return nil, fmt.Errorf("a grace period of 0 deletes immediately, check Force to do it")
+		}
+		options.GracePeriodSeconds = &seconds
+	}
+	if force {
+		var zero int64
+		options.GracePeriodSeconds = &zero
+	}
+	if propagation != defaultPropagation {
+		policy := metav1.DeletionPropagation(propagation)
+		options.PropagationPolicy = &policy
+	}
+	return options, nil
+}
+
+/*
+removeFinalizers shows the finalizers of the terminating objects, and removes them once confirmed. The controllers
+behind the finalizers are then skipped, so whatever they were to clean up is left behind.
+*/
+func removeFinalizers(t *throwing.TableView, r apiResource, terminating []*unstructured.Unstructured) {
+	var lines []string
+	var objects []objectRef
+	for _, obj := range terminating {
+		o := objectRef{obj.GetNamespace(), obj.GetName()}
+		objects = append(objects, o)
+		finalizers := strings.Join(obj.GetFinalizers(), ", ")
+		if finalizers == "" {
+			finalizers = "none"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", objectName(o), finalizers))
+	}
+
+	text := fmt.Sprintf("Remove the finalizers of %s?\n\n%s\n\nWhat they clean up will be left behind.",
+		describeObjects(t, objects), strings.Join(lines, "\n"))
+	confirm(t, "remove", text, func() {
+		applyToObjects(t, "remove finalizers", objects, func(o objectRef) error {
+			client, err := resourceClient(r, o.namespace)
+			if err != nil {
+				return err
+			}
+			_, err = client.Patch(o.name, k8stypes.MergePatchType, []byte(removeFinalizersPatch), metav1.UpdateOptions{})
+			return err
+		})
+	})
+}
+
+// deleteObject deletes an object after saving its manifest in the journal of deleted objects.
+func deleteObject(clientset *kubernetes.Clientset, kind, namespace, name string, options *metav1.DeleteOptions) error {
+	r, err := lookupResource(clientset, kind)
+	if err != nil {
+		return err
+	}
+	client, err := resourceClient(r, namespace)
+	if err != nil {
+		return err
+	}
+
+	// keep the manifest to recreate the object from the recently deleted page
+	obj, err := client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	file, err := journalDeleted(obj)
+	if err != nil {
+		return fmt.Errorf("not deleted, failed to save the manifest: %v", err)
+	}
+	// only the object saved is deleted, not one created again with the same name meanwhile
+	uid := obj.GetUID()
+	precondition := *options
+	precondition.Preconditions = &metav1.Preconditions{UID: &uid}
+	if err := client.Delete(name, &precondition); err != nil {
+		if file != "" {
+			os.Remove(file)
+		}
+		return err
+	}
+	return nil
+}
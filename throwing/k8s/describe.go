@@ -0,0 +1,363 @@
+package k8s
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/rancher/axe/throwing"
+	"github.com/rancher/norman/types/convert"
+	"github.com/rivo/tview"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	maxOwnerDepth = 10
+)
+
+func describe(t *throwing.TableView) {
+	namespace, name := getNamespaceAndName(t)
+	r, err := lookupResource(t.GetClientSet(), t.GetResourceKind())
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	client, err := resourceClient(r, namespace)
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	obj, err := client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+
+	out := &strings.Builder{}
+	describeObject(out, t.GetClientSet(), obj)
+
+	box := tview.NewTextView()
+	box.SetTitle(fmt.Sprintf("describe - (%s)", name))
+	box.SetBorder(true)
+	box.SetTitleColor(tcell.ColorPurple)
+	box.SetDynamicColors(true).SetBackgroundColor(tcell.ColorBlack)
+	box.SetText(out.String())
+	box.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			t.SwitchToRootPage()
+		}
+	})
+
+	newpage := tview.NewPages().AddPage("describe", box, true, true)
+	t.SwitchPage(t.GetCurrentPage(), newpage)
+}
+
+func describeObject(out io.Writer, clientset *kubernetes.Clientset, obj *unstructured.Unstructured) {
+	describeMetadata(out, obj)
+	describeOwners(out, clientset, obj)
+	describeStatus(out, obj)
+	describeConditions(out, obj)
+	describeEvents(out, clientset, obj)
+}
+
+func section(out io.Writer, title string) {
+	fmt.Fprintf(out, "\n[yellow::b]%s[white::-]\n", title)
+}
+
+func describeMetadata(out io.Writer, obj *unstructured.Unstructured) {
+	section(out, "Metadata")
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "  Name:\t%s\n", obj.GetName())
+	if obj.GetNamespace() != "" {
+		fmt.Fprintf(w, "  Namespace:\t%s\n", obj.GetNamespace())
+	}
+	fmt.Fprintf(w, "  Kind:\t%s\n", obj.GetKind())
+	fmt.Fprintf(w, "  API Version:\t%s\n", obj.GetAPIVersion())
+	fmt.Fprintf(w, "  UID:\t%s\n", obj.GetUID())
+	created := obj.GetCreationTimestamp()
+	fmt.Fprintf(w, "  Created:\t%s (%s ago)\n", created.Format(time.RFC3339), since(created))
+	if deleted := obj.GetDeletionTimestamp(); deleted != nil {
+		fmt.Fprintf(w, "  Deleting Since:\t%s\n", deleted.Format(time.RFC3339))
+	}
+	if finalizers := obj.GetFinalizers(); len(finalizers) > 0 {
+		fmt.Fprintf(w, "  Finalizers:\t%s\n", strings.Join(finalizers, ", "))
+	}
+	writeMap(w, "Labels", obj.GetLabels())
+	writeMap(w, "Annotations", obj.GetAnnotations())
+	w.Flush()
+}
+
+func writeMap(w io.Writer, title string, m map[string]string) {
+	if len(m) == 0 {
+		fmt.Fprintf(w, "  %s:\t<none>\n", title)
+		return
+	}
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		label := ""
+		if i == 0 {
+			label = title + ":"
+		}
+		fmt.Fprintf(w, "  %s\t%s=%s\n", label, tview.Escape(k), tview.Escape(truncate(m[k], 80)))
+	}
+}
+
+// describeOwners follows the controller owner reference, or the first one, up to the top level owner.
+func describeOwners(out io.Writer, clientset *kubernetes.Clientset, obj *unstructured.Unstructured) {
+	if len(obj.GetOwnerReferences()) == 0 {
+		return
+	}
+	section(out, "Owners")
+
+	chain := []string{fmt.Sprintf("%s/%s", obj.GetKind(), obj.GetName())}
+	current := obj
+	for i := 0; i < maxOwnerDepth; i++ {
+		refs := current.GetOwnerReferences()
+		if len(refs) == 0 {
+			break
+		}
+		ref := refs[0]
+		for _, r := range refs {
+			if r.Controller != nil && *r.Controller {
+				ref = r
+			}
+		}
+		chain = append(chain, fmt.Sprintf("%s/%s", ref.Kind, ref.Name))
+
+		owner, err := getOwner(clientset, current.GetNamespace(), ref)
+		if err != nil {
+			chain[len(chain)-1] += fmt.Sprintf(" [red](%v)[white]", err)
+			break
+		}
+		current = owner
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		fmt.Fprintf(out, "  %s%s\n", strings.Repeat("  ", len(chain)-1-i), chain[i])
+	}
+}
+
+func getOwner(clientset *kubernetes.Clientset, namespace string, ref metav1.OwnerReference) (*unstructured.Unstructured, error) {
+	r, err := lookupKind(clientset, ref.APIVersion, ref.Kind)
+	if err != nil {
+		return nil, err
+	}
+	client, err := resourceClient(r, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return client.Get(ref.Name, metav1.GetOptions{})
+}
+
+func describeStatus(out io.Writer, obj *unstructured.Unstructured) {
+	switch obj.GetKind() {
+	case "Pod":
+		describePodStatus(out, obj)
+	case "Deployment", "ReplicaSet", "StatefulSet", "ReplicationController":
+		describeReplicas(out, obj)
+	case "DaemonSet":
+		describeDaemonSet(out, obj)
+	case "Node":
+		describeNode(out, obj)
+	}
+}
+
+func describePodStatus(out io.Writer, obj *unstructured.Unstructured) {
+	section(out, "Status")
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "  Phase:\t%s\n", nestedString(obj, "status", "phase"))
+	fmt.Fprintf(w, "  Node:\t%s\n", nestedString(obj, "spec", "nodeName"))
+	fmt.Fprintf(w, "  Pod IP:\t%s\n", nestedString(obj, "status", "podIP"))
+	fmt.Fprintf(w, "  QoS Class:\t%s\n", nestedString(obj, "status", "qosClass"))
+	w.Flush()
+
+	section(out, "Containers")
+	w = tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "  NAME\tIMAGE\tREADY\tSTATE\tRESTARTS")
+	statuses, _, _ := unstructured.NestedSlice(obj.Object, "status", "containerStatuses")
+	for _, s := range statuses {
+		status := convert.ToMapInterface(s)
+		fmt.Fprintf(w, "  %s\t%s\t%v\t%s\t%v\n",
+			convert.ToString(status["name"]),
+			convert.ToString(status["image"]),
+			status["ready"],
+			containerState(convert.ToMapInterface(status["state"])),
+			status["restartCount"])
+	}
+	w.Flush()
+}
+
+// containerState renders a container state the way kubectl does, e.g. `Waiting (CrashLoopBackOff)`.
+func containerState(state map[string]interface{}) string {
+	for _, s := range []string{"running", "waiting", "terminated"} {
+		detail, ok := state[s]
+		if !ok {
+			continue
+		}
+		title := strings.Title(s)
+		if reason := convert.ToString(convert.ToMapInterface(detail)["reason"]); reason != "" {
+			return fmt.Sprintf("%s (%s)", title, reason)
+		}
+		return title
+	}
+	return "Unknown"
+}
+
+func describeReplicas(out io.Writer, obj *unstructured.Unstructured) {
+	section(out, "Replicas")
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "  DESIRED\tCURRENT\tUPDATED\tREADY\tAVAILABLE")
+	fmt.Fprintf(w, "  %d\t%d\t%d\t%d\t%d\n",
+		nestedInt(obj, "spec", "replicas"),
+		nestedInt(obj, "status", "replicas"),
+		nestedInt(obj, "status", "updatedReplicas"),
+		nestedInt(obj, "status", "readyReplicas"),
+		nestedInt(obj, "status", "availableReplicas"))
+	w.Flush()
+}
+
+func describeDaemonSet(out io.Writer, obj *unstructured.Unstructured) {
+	section(out, "Scheduling")
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "  DESIRED\tCURRENT\tUPDATED\tREADY\tAVAILABLE\tMISSCHEDULED")
+	fmt.Fprintf(w, "  %d\t%d\t%d\t%d\t%d\t%d\n",
+		nestedInt(obj, "status", "desiredNumberScheduled"),
+		nestedInt(obj, "status", "currentNumberScheduled"),
+		nestedInt(obj, "status", "updatedNumberScheduled"),
+		nestedInt(obj, "status", "numberReady"),
+		nestedInt(obj, "status", "numberAvailable"),
+		nestedInt(obj, "status", "numberMisscheduled"))
+	w.Flush()
+}
+
+func describeNode(out io.Writer, obj *unstructured.Unstructured) {
+	section(out, "Node")
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	unschedulable, _, _ := unstructured.NestedBool(obj.Object, "spec", "unschedulable")
+	fmt.Fprintf(w, "  Unschedulable:\t%v\n", unschedulable)
+	fmt.Fprintf(w, "  Kubelet Version:\t%s\n", nestedString(obj, "status", "nodeInfo", "kubeletVersion"))
+	fmt.Fprintf(w, "  OS Image:\t%s\n", nestedString(obj, "status", "nodeInfo", "osImage"))
+	addresses, _, _ := unstructured.NestedSlice(obj.Object, "status", "addresses")
+	for _, a := range addresses {
+		address := convert.ToMapInterface(a)
+		fmt.Fprintf(w, "  %s:\t%s\n", convert.ToString(address["type"]), convert.ToString(address["address"]))
+	}
+	for _, field := range []string{"capacity", "allocatable"} {
+		resources, _, _ := unstructured.NestedStringMap(obj.Object, "status", field)
+		fmt.Fprintf(w, "  %s:\tcpu=%s memory=%s pods=%s\n", strings.Title(field), resources["cpu"], resources["memory"], resources["pods"])
+	}
+	w.Flush()
+}
+
+func describeConditions(out io.Writer, obj *unstructured.Unstructured) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if len(conditions) == 0 {
+		return
+	}
+	section(out, "Conditions")
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "  TYPE\tSTATUS\tREASON\tLAST TRANSITION\tMESSAGE")
+	for _, c := range conditions {
+		condition := convert.ToMapInterface(c)
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n",
+			convert.ToString(condition["type"]),
+			convert.ToString(condition["status"]),
+			convert.ToString(condition["reason"]),
+			sinceString(convert.ToString(condition["lastTransitionTime"])),
+			tview.Escape(convert.ToString(condition["message"])))
+	}
+	w.Flush()
+}
+
+func describeEvents(out io.Writer, clientset *kubernetes.Clientset, obj *unstructured.Unstructured) {
+	section(out, "Events")
+	events, err := involvedEvents(clientset, obj)
+	if err != nil {
+		fmt.Fprintf(out, "  [red]%s[white]\n", tview.Escape(err.Error()))
+		return
+	}
+	if len(events) == 0 {
+		fmt.Fprintln(out, "  <none>")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "  TYPE\tREASON\tLAST SEEN\tCOUNT\tFROM\tMESSAGE")
+	for _, e := range events {
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%d\t%s\t%s\n",
+			e.Type,
+			e.Reason,
+			since(eventTime(e)),
+			e.Count,
+			e.Source.Component,
+			tview.Escape(strings.TrimSpace(e.Message)))
+	}
+	w.Flush()
+}
+
+// involvedEvents lists the events whose involvedObject is obj, oldest first.
+func involvedEvents(clientset *kubernetes.Clientset, obj *unstructured.Unstructured) ([]corev1.Event, error) {
+	selector := fields.Set{
+		"involvedObject.name": obj.GetName(),
+		"involvedObject.uid":  string(obj.GetUID()),
+	}
+	list, err := clientset.CoreV1().Events(obj.GetNamespace()).List(metav1.ListOptions{
+		FieldSelector: selector.AsSelector().String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	events := list.Items
+	sort.Slice(events, func(i, j int) bool {
+		return eventTime(events[i]).Time.Before(eventTime(events[j]).Time)
+	})
+	return events, nil
+}
+
+func eventTime(e corev1.Event) metav1.Time {
+	if !e.LastTimestamp.IsZero() {
+		return e.LastTimestamp
+	}
+	if !e.EventTime.IsZero() {
+		return metav1.NewTime(e.EventTime.Time)
+	}
+	return e.FirstTimestamp
+}
+
+func since(t metav1.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(time.Since(t.Time))
+}
+
+func sinceString(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return "<unknown>"
+	}
+	return since(metav1.NewTime(t))
+}
+
+func nestedString(obj *unstructured.Unstructured, fields ...string) string {
+	s, _, _ := unstructured.NestedString(obj.Object, fields...)
+	return s
+}
+
+func nestedInt(obj *unstructured.Unstructured, fields ...string) int64 {
+	i, _, _ := unstructured.NestedInt64(obj.Object, fields...)
+	return i
+}
@@ -0,0 +1,214 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/tview"
+)
+
+const (
+	// diffContext is the number of unchanged lines shown around changes
+	diffContext = 3
+	// sideBySideWidth bounds the width of the left column of side by side diffs
+	sideBySideWidth = 80
+)
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+/*
+unifiedDiff returns the unified diff of two texts, or an empty string if they are the same. Lines are matched with a
+longest common subsequence, which is plenty for manifests.
+*/
+func unifiedDiff(a, b, fromName, toName string) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	out := &strings.Builder{}
+	fmt.Fprintf(out, "--- %s\n+++ %s\n", fromName, toName)
+	for start := 0; start < len(ops); {
+		// find the next change, and the end of the hunk around it
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		from := maxInt(first-diffContext, start)
+		to := first
+		for unchanged := 0; to < len(ops) && unchanged <= 2*diffContext; to++ {
+			if ops[to].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		// drop the trailing context beyond what is shown
+		for to > first && ops[to-1].kind == ' ' && countTrailing(ops[first:to]) > diffContext {
+			to--
+		}
+
+		aStart, bStart := lineNumbers(ops[:from])
+		aLen, bLen := lineNumbers(ops[from:to])
+		fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", aStart+1, aLen, bStart+1, bLen)
+		for _, op := range ops[from:to] {
+			fmt.Fprintf(out, "%c%s\n", op.kind, op.line)
+		}
+		start = to
+	}
+	return out.String()
+}
+
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = maxInt(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// lineNumbers returns how many lines of each text the operations span.
+func lineNumbers(ops []diffOp) (int, int) {
+	a, b := 0, 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			a++
+		}
+		if op.kind != '-' {
+			b++
+		}
+	}
+	return a, b
+}
+
+func countTrailing(ops []diffOp) int {
+	n := 0
+	for i := len(ops) - 1; i >= 0 && ops[i].kind == ' '; i-- {
+		n++
+	}
+	return n
+}
+
+// colorDiff colors a unified diff for a TextView with dynamic colors.
+func colorDiff(diff string) string {
+	out := &strings.Builder{}
+	for _, line := range splitLines(diff) {
+		color := "white"
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			color = "yellow"
+		case strings.HasPrefix(line, "@@"):
+			color = "teal"
+		case strings.HasPrefix(line, "+"):
+			color = "green"
+		case strings.HasPrefix(line, "-"):
+			color = "red"
+		}
+		fmt.Fprintf(out, "[%s]%s[white]\n", color, tview.Escape(line))
+	}
+	return out.String()
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+/*
+sideBySide returns the diff of two texts in two columns, for a TextView with dynamic colors. Removed and added lines
+are paired up, so that a changed line shows next to what it replaced.
+*/
+func sideBySide(a, b, fromName, toName string) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+	width := len(fromName)
+	for _, op := range ops {
+		if op.kind != '+' {
+			width = maxInt(width, len(op.line))
+		}
+	}
+	if width > sideBySideWidth {
+		width = sideBySideWidth
+	}
+
+	out := &strings.Builder{}
+	row := func(left, right, leftColor, rightColor string) {
+		if len(left) > width {
+			left = left[:width-1] + "~"
+		}
+		fmt.Fprintf(out, "[%s]%s%s[white] │ [%s]%s[white]\n", leftColor, tview.Escape(left), strings.Repeat(" ", width-len(left)), rightColor, tview.Escape(right))
+	}
+	row(fromName, toName, "yellow", "yellow")
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			row(ops[i].line, ops[i].line, "white", "white")
+			i++
+			continue
+		}
+		var removed, added []string
+		for ; i < len(ops) && ops[i].kind == '-'; i++ {
+			removed = append(removed, ops[i].line)
+		}
+		for ; i < len(ops) && ops[i].kind == '+'; i++ {
+			added = append(added, ops[i].line)
+		}
+		for j := 0; j < maxInt(len(removed), len(added)); j++ {
+			left, right := "", ""
+			if j < len(removed) {
+				left = removed[j]
+			}
+			if j < len(added) {
+				right = added[j]
+			}
+			row(left, right, "red", "green")
+		}
+	}
+	return out.String()
+}
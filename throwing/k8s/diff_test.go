@@ -0,0 +1,85 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "same",
+			a:    "a\nb\n",
+			b:    "a\nb\n",
+			want: "",
+		},
+		{
+			name: "changed line",
+			a:    "a\nb\nc\n",
+			b:    "a\nx\nc\n",
+			want: "--- from\n+++ to\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n",
+		},
+		{
+			name: "added to empty",
+			a:    "",
+			b:    "a\n",
+			want: "--- from\n+++ to\n@@ -1,0 +1,1 @@\n+a\n",
+		},
+		{
+			name: "context bounded",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n",
+			b:    "1\n2\n3\n4\n5\n6\n7\nx\n",
+			want: "--- from\n+++ to\n@@ -5,4 +5,4 @@\n 5\n 6\n 7\n-8\n+x\n",
+		},
+		{
+			name: "two hunks",
+			a:    "a\n1\n2\n3\n4\n5\n6\n7\n8\nb\n",
+			b:    "x\n1\n2\n3\n4\n5\n6\n7\n8\ny\n",
+			want: "--- from\n+++ to\n@@ -1,4 +1,4 @@\n-a\n+x\n 1\n 2\n 3\n@@ -7,4 +7,4 @@\n 6\n 7\n 8\n-b\n+y\n",
+		},
+	}
+	for _, tt := range tests {
+		if got := unifiedDiff(tt.a, tt.b, "from", "to"); got != tt.want {
+			t.Errorf("%s: unifiedDiff() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSideBySide(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []string
+	}{
+		{
+			name: "changed line paired",
+			a:    "a\nb\n",
+			b:    "a\nx\n",
+			want: []string{
+				"[yellow]from[white] │ [yellow]to[white]",
+				"[white]a   [white] │ [white]a[white]",
+				"[red]b   [white] │ [green]x[white]",
+			},
+		},
+		{
+			name: "added line",
+			a:    "a\n",
+			b:    "a\nb\n",
+			want: []string{
+				"[yellow]from[white] │ [yellow]to[white]",
+				"[white]a   [white] │ [white]a[white]",
+				"[red]    [white] │ [green]b[white]",
+			},
+		},
+	}
+	for _, tt := range tests {
+		got := strings.Split(strings.TrimSuffix(sideBySide(tt.a, tt.b, "from", "to"), "\n"), "\n")
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: sideBySide() =\n%s\nwant\n%s", tt.name, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
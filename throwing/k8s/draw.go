@@ -36,10 +36,43 @@ var (
 
 	Shortcuts = [][]string{
 		{"Key g", "Get"},
+		{"Key D", "Describe"},
 		{"Key e", "Edit"},
 		{"Key d", "Delete"},
 		{"Key l", "Logs"},
 		{"Key x", "Exec"},
+		{"Key a", "Attach"},
+		{"Key b", "Browse and copy files"},
+		{"Key t", "Terminals"},
+		{"Key f", "Port-forward"},
+		{"Key F", "Port-forwards"},
+		{"Key X", "Xray"},
+		{"Key U", "Used by"},
+		{"Key O", "Unused resources"},
+		{"Key P", "Problems"},
+		{"Key E", "Events"},
+		{"Key Z", "Recently deleted"},
+		{"Key I", "Apply manifests"},
+		{"Key N", "New from template"},
+		{"Key W", "Field owners"},
+		{"Key V", "Diff last-applied, or two marked"},
+		{"Key K", "Compare with another context"},
+		{"Key S", "Config map or secret keys"},
+		{"Key C", "Create config map or secret"},
+		{"Key s", "Scale"},
+		{"Key R", "Restart"},
+		{"Key H", "Rollout history"},
+		{"Key c", "Cordon"},
+		{"Key u", "Uncordon"},
+		{"Key n", "Drain"},
+		{"Key L", "Labels"},
+		{"Key A", "Annotations"},
+		{"Key T", "Taints"},
+		{"Key Space", "Mark"},
+		{"Key M", "Mark all"},
+		{"Key m", "Mark matching"},
+		{"Key Enter", "Owned objects"},
+		{"Key Esc", "Back"},
 		{"key r", "Refresh"},
 		{"Key /", "Search"},
 		{"Key q", "quit to root page"},
@@ -50,17 +83,22 @@ var (
 			Actions: []types.Action{
 				{
 					Name:        "get",
-					Shortcut:    'g',
+					Shortcut:    "g",
 					Description: "get a resource",
 				},
+				{
+					Name:        "describe",
+					Shortcut:    "D",
+					Description: "describe a resource",
+				},
 				{
 					Name:        "edit",
-					Shortcut:    'e',
+					Shortcut:    "e",
 					Description: "edit a resource",
 				},
 				{
 					Name:        "delete",
-					Shortcut:    'd',
+					Shortcut:    "d",
 					Description: "delete a resource",
 				},
 			},
@@ -82,36 +120,30 @@ var (
 					t.ShowSearch()
 				case 'r':
 					t.Refresh()
+				case 't':
+					t.ShowTerminals()
+				case 'F':
+					viewPortForwards(t)
+				case 'O':
+					viewUnused(t)
+				case 'P':
+					viewProblems(t)
+				case 'E':
+					viewEvents(t)
+				case 'Z':
+					viewJournal(t)
+				case 'I':
+					applyDialog(t)
+				case 'N':
+					templateDialog(t)
+				case 'C':
+					createDataDialog(t)
 				}
 			}
 			return event
 		}
 	}
 
-	itemEventHandler = func(t *throwing.TableView) func(event *tcell.EventKey) *tcell.EventKey {
-		return func(event *tcell.EventKey) *tcell.EventKey {
-			switch event.Rune() {
-			case 'g':
-				get(t)
-			case 'e':
-				edit(t)
-			case 'd':
-				delete(t)
-			case 'x':
-				execute(t)
-			case 'l':
-				logs(t)
-			case 'q':
-				t.RootPage()
-			case 'r':
-				t.Refresh()
-			case '/':
-				t.ShowSearch()
-			}
-			return event
-		}
-	}
-
 	drawer = types.Drawer{
 		RootPage:  RootPage,
 		Shortcuts: Shortcuts,
@@ -124,6 +156,13 @@ var (
 func Start(c *cli.Context) error {
 	kubeconfig := c.String("kubeconfig")
 	os.Setenv("KUBECONFIG", kubeconfig)
+	if manager := c.String("field-manager"); manager != "" {
+		fieldManager = manager
+	}
+	if age := c.Duration("completed-job-age"); age > 0 {
+		completedJobAge = age
+	}
+	journalSecrets = c.Bool("journal-secrets")
 
 	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
 	if err != nil {
@@ -138,5 +177,101 @@ func Start(c *cli.Context) error {
 	if err := app.Init(); err != nil {
 		return err
 	}
+	defer forwards.StopAll()
 	return app.Run()
 }
+
+// itemEventHandler handles the keys of the tables listing the objects of a resource. It is a function, not a variable like
+// the other handlers, because drilling down opens tables that use it again.
+func itemEventHandler(t *throwing.TableView) func(event *tcell.EventKey) *tcell.EventKey {
+	return func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			drillDown(t)
+		case tcell.KeyEscape:
+			t.BackPage()
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'g':
+				get(t)
+			case 'D':
+				describe(t)
+			case 'e':
+				edit(t)
+			case 'd':
+				deleteSelected(t)
+			case 'x':
+				execute(t)
+			case 'a':
+				attach(t)
+			case 'b':
+				browseFiles(t)
+			case 't':
+				t.ShowTerminals()
+			case 'f':
+				portForwardDialog(t)
+			case 'F':
+				viewPortForwards(t)
+			case 'X':
+				xray(t)
+			case 'U':
+				usedBy(t)
+			case 'O':
+				viewUnused(t)
+			case 'P':
+				viewProblems(t)
+			case 'E':
+				viewEvents(t)
+			case 'Z':
+				viewJournal(t)
+			case 'I':
+				applyDialog(t)
+			case 'N':
+				templateDialog(t)
+			case 'W':
+				viewFieldOwners(t)
+			case 'V':
+				diffObjects(t)
+			case 'K':
+				compareContextDialog(t)
+			case 'S':
+				viewData(t)
+			case 'C':
+				createDataDialog(t)
+			case 's':
+				scale(t)
+			case 'R':
+				restart(t)
+			case 'H':
+				history(t)
+			case 'c':
+				cordon(t, true)
+			case 'u':
+				cordon(t, false)
+			case 'n':
+				drain(t)
+			case 'L':
+				editLabels(t)
+			case 'A':
+				editAnnotations(t)
+			case 'T':
+				editTaints(t)
+			case ' ':
+				t.ToggleMark()
+			case 'M':
+				t.MarkAll()
+			case 'm':
+				markDialog(t)
+			case 'l':
+				logs(t)
+			case 'q':
+				t.RootPage()
+			case 'r':
+				t.Refresh()
+			case '/':
+				t.ShowSearch()
+			}
+		}
+		return event
+	}
+}
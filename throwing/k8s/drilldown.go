@@ -0,0 +1,353 @@
+package k8s
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gdamore/tcell"
+	"github.com/rancher/axe/throwing"
+	"github.com/rancher/axe/throwing/datafeeder"
+	"github.com/rancher/axe/throwing/types"
+	"github.com/rivo/tview"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	containerKind = "containers"
+)
+
+var (
+	containerActions = []types.Action{
+		{
+			Name:        "logs",
+			Shortcut:    "l",
+			Description: "logs of a container",
+		},
+	}
+
+	// ownedKinds are the table kinds that the built-in controllers create for the objects of a kind
+	ownedKinds = map[string][]string{
+		"Deployment":  {"replicasets.apps"},
+		"ReplicaSet":  {"pods"},
+		"StatefulSet": {"pods", "controllerrevisions.apps"},
+		"DaemonSet":   {"pods", "controllerrevisions.apps"},
+		"Job":         {"pods"},
+		"CronJob":     {"jobs.batch"},
+	}
+
+	containerEventHandler = func(namespace, pod string) throwing.EventHandler {
+		return func(t *throwing.TableView) func(event *tcell.EventKey) *tcell.EventKey {
+			return func(event *tcell.EventKey) *tcell.EventKey {
+				switch event.Key() {
+				case tcell.KeyEscape:
+					t.BackPage()
+				case tcell.KeyRune:
+					switch event.Rune() {
+					case 'l':
+						showLogs(t, namespace, pod, t.GetSelectionName())
+					case 'r':
+						t.RefreshManual()
+					case 'q':
+						t.RootPage()
+					case '/':
+						t.ShowSearch()
+					}
+				}
+				return event
+			}
+		}
+	}
+)
+
+/*
+drillDown opens the objects owned by the selected row. A pod opens the table of its containers, any other object the
+objects whose ownerReferences point at it. If it owns objects of several kinds, a picker asks which kind to show.
+*/
+func drillDown(t *throwing.TableView) {
+	namespace, name := getNamespaceAndName(t)
+	if t.GetResourceKind() == "pods" {
+		viewContainers(t, namespace, name)
+		return
+	}
+
+	r, err := lookupResource(t.GetClientSet(), t.GetResourceKind())
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	client, err := resourceClient(r, namespace)
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	owner, err := client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+
+	owned, err := ownedResources(t.GetClientSet(), owner)
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	switch len(owned) {
+	case 0:
+		t.UpdateStatus(fmt.Sprintf("%s %s does not own any object", owner.GetKind(), name), false)
+	case 1:
+		viewOwned(t, owned[0], owner)
+	default:
+		var kinds []string
+		for _, r := range owned {
+			kinds = append(kinds, r.tableKind())
+		}
+		index := 0
+
+		form := tview.NewForm()
+		form.SetBorder(true).SetTitle(fmt.Sprintf("owned by - (%s)", name))
+		form.AddDropDown("Kind", kinds, 0, func(option string, optionIndex int) {
+			index = optionIndex
+		})
+		form.AddButton("open", func() {
+			viewOwned(t, owned[index], owner)
+		})
+		form.AddButton("Cancel", func() {
+			t.BackPage()
+		})
+		form.SetCancelFunc(func() {
+			t.BackPage()
+		})
+		t.InsertDialog("owned", t.GetCurrentPrimitive(), form)
+	}
+}
+
+/*
+ownedResources returns the resources that have at least one object owned by owner. The kinds the owner is known to own
+are searched first, every resource is only searched if none of them has such an object, e.g. for custom resources.
+Owners only own objects in their own namespace, so only that namespace is searched unless the owner is cluster scoped.
+*/
+func ownedResources(clientset *kubernetes.Clientset, owner *unstructured.Unstructured) ([]apiResource, error) {
+	var known []apiResource
+	for _, kind := range ownedKinds[owner.GetKind()] {
+		if r, err := lookupResource(clientset, kind); err == nil {
+			known = append(known, r)
+		}
+	}
+	if owned := resourcesOwnedBy(owner, known); len(owned) > 0 {
+		return owned, nil
+	}
+
+	lists, err := clientset.Discovery().ServerPreferredResources()
+	if err != nil && len(lists) == 0 {
+		return nil, err
+	}
+	var all []apiResource
+	for _, l := range lists {
+		gv, err := schema.ParseGroupVersion(l.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, res := range l.APIResources {
+			r := newAPIResource(gv, res)
+			if !r.can("list") || strings.Contains(res.Name, "/") || res.Name == "events" {
+				continue
+			}
+			if owner.GetNamespace() != "" && !r.namespaced {
+				continue
+			}
+			all = append(all, r)
+		}
+	}
+	return resourcesOwnedBy(owner, all), nil
+}
+
+// resourcesOwnedBy lists the resources in parallel and returns those with an object owned by owner, sorted.
+func resourcesOwnedBy(owner *unstructured.Unstructured, resources []apiResource) []apiResource {
+	var (
+		wg    sync.WaitGroup
+		lock  sync.Mutex
+		owned []apiResource
+	)
+	for _, r := range resources {
+		r := r
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, err := resourceClient(r, owner.GetNamespace())
+			if err != nil {
+				return
+			}
+			list, err := client.List(metav1.ListOptions{})
+			if err != nil {
+				return
+			}
+			for i := range list.Items {
+				if ownedBy(&list.Items[i], owner.GetUID()) {
+					lock.Lock()
+					owned = append(owned, r)
+					lock.Unlock()
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(owned, func(i, j int) bool {
+		return owned[i].tableKind() < owned[j].tableKind()
+	})
+	return owned
+}
+
+func ownedBy(object metav1.Object, uid k8stypes.UID) bool {
+	for _, ref := range object.GetOwnerReferences() {
+		if ref.UID == uid {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+viewOwned shows the table of the objects of one resource owned by owner. The table keeps the kind of the resource, so
+that every action works in it as in the full table, but it gets a page of its own for each owner.
+*/
+func viewOwned(t *throwing.TableView, r apiResource, owner *unstructured.Unstructured) {
+	rkind := types.ResourceKind{
+		Title: fmt.Sprintf("%s - %s/%s", r.tableKind(), owner.GetKind(), owner.GetName()),
+		Kind:  r.tableKind(),
+	}
+	page := fmt.Sprintf("%s@%s", r.tableKind(), owner.GetUID())
+
+	newtable := t.GetNestedTable(page)
+	if newtable == nil {
+		w := wrapper{
+			group:     r.gvr.Group,
+			version:   r.gvr.Version,
+			name:      r.gvr.Resource,
+			namespace: owner.GetNamespace(),
+			ownerUID:  owner.GetUID(),
+		}
+		newtable = t.NewNestTableView(rkind, datafeeder.NewDataFeeder(w.refreshResource), nil, nil, itemEventHandler)
+		t.SetTableView(page, newtable)
+	} else {
+		newtable.RefreshManual()
+	}
+	t.SwitchPage(page, newtable)
+}
+
+func viewContainers(t *throwing.TableView, namespace, name string) {
+	rkind := types.ResourceKind{
+		Title: fmt.Sprintf("%s - Pod/%s", containerKind, name),
+		Kind:  containerKind,
+	}
+	page := fmt.Sprintf("%s@%s/%s", containerKind, namespace, name)
+
+	newtable := t.GetNestedTable(page)
+	if newtable == nil {
+		refresh := func(b *bytes.Buffer) error {
+			return refreshContainers(t.GetClientSet(), namespace, name, b)
+		}
+		newtable = t.NewNestTableView(rkind, datafeeder.NewDataFeeder(refresh), containerActions, nil, containerEventHandler(namespace, name))
+		t.SetTableView(page, newtable)
+	} else {
+		newtable.RefreshManual()
+	}
+	t.SwitchPage(page, newtable)
+}
+
+func refreshContainers(clientset *kubernetes.Clientset, namespace, name string, b *bytes.Buffer) error {
+	pod, err := clientset.CoreV1().Pods(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	statuses := map[string]corev1.ContainerStatus{}
+	for _, s := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		statuses[s.Name] = s
+	}
+
+	b.WriteString("NAME\tTYPE\tIMAGE\tSTATE\tREADY\tRESTARTS\tPORTS\tPROBES")
+	write := func(c corev1.Container, kind string) {
+		status, ok := statuses[c.Name]
+		state := "Unknown"
+		if ok {
+			state = typedContainerState(status.State)
+		}
+		fmt.Fprintf(b, "\n%s\t%s\t%s\t%s\t%t\t%d\t%s\t%s", c.Name, kind, c.Image, state, status.Ready, status.RestartCount,
+			containerPorts(c), containerProbes(c))
+	}
+	for _, c := range pod.Spec.InitContainers {
+		write(c, "init")
+	}
+	for _, c := range pod.Spec.Containers {
+		write(c, "app")
+	}
+	return nil
+}
+
+func typedContainerState(state corev1.ContainerState) string {
+	switch {
+	case state.Running != nil:
+		return "Running"
+	case state.Waiting != nil && state.Waiting.Reason != "":
+		return fmt.Sprintf("Waiting (%s)", state.Waiting.Reason)
+	case state.Waiting != nil:
+		return "Waiting"
+	case state.Terminated != nil && state.Terminated.Reason != "":
+		return fmt.Sprintf("Terminated (%s)", state.Terminated.Reason)
+	case state.Terminated != nil:
+		return "Terminated"
+	}
+	return "Unknown"
+}
+
+func containerPorts(c corev1.Container) string {
+	var ports []string
+	for _, p := range c.Ports {
+		port := fmt.Sprintf("%d/%s", p.ContainerPort, p.Protocol)
+		if p.Name != "" {
+			port = p.Name + ":" + port
+		}
+		ports = append(ports, port)
+	}
+	if len(ports) == 0 {
+		return "<none>"
+	}
+	return strings.Join(ports, ",")
+}
+
+func containerProbes(c corev1.Container) string {
+	var probes []string
+	if c.LivenessProbe != nil {
+		probes = append(probes, "liveness "+probeHandler(c.LivenessProbe.Handler))
+	}
+	if c.ReadinessProbe != nil {
+		probes = append(probes, "readiness "+probeHandler(c.ReadinessProbe.Handler))
+	}
+	if len(probes) == 0 {
+		return "<none>"
+	}
+	return strings.Join(probes, ", ")
+}
+
+func probeHandler(h corev1.Handler) string {
+	switch {
+	case h.HTTPGet != nil:
+		return fmt.Sprintf("http-get :%s%s", h.HTTPGet.Port.String(), h.HTTPGet.Path)
+	case h.TCPSocket != nil:
+		return fmt.Sprintf("tcp :%s", h.TCPSocket.Port.String())
+	case h.Exec != nil:
+		// commands can span lines, keep them on the single line of the cell
+		return "exec " + strings.Join(strings.Fields(strings.Join(h.Exec.Command, " ")), " ")
+	}
+	return "unknown"
+}
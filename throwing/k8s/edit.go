@@ -0,0 +1,437 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/ghodss/yaml"
+	"github.com/rancher/axe/throwing"
+	"github.com/rivo/tview"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	defaultEditor = "vi"
+)
+
+// editorCommand runs the editor of the user on a file, as kubectl edit picks it.
+func editorCommand(file string) *exec.Cmd {
+	editor := os.Getenv("KUBE_EDITOR")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = defaultEditor
+	}
+	args := append(strings.Fields(editor), file)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd
+}
+
+// editText opens a text in the editor of the user, with axe suspended, and returns the text saved.
+func editText(t *throwing.TableView, name, text string) (string, error) {
+	file, err := ioutil.TempFile("", "axe-"+name+"-*.yaml")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		return "", err
+	}
+	file.Close()
+
+	t.GetApplication().Suspend(func() {
+		clearScreen()
+		err = editorCommand(file.Name()).Run()
+	})
+	if err != nil {
+		return "", err
+	}
+	data, err := ioutil.ReadFile(file.Name())
+	return string(data), err
+}
+
+// diffView returns the YAML of an object as compared in diffs, without the fields that change with every write.
+func diffView(obj *unstructured.Unstructured) string {
+	obj = obj.DeepCopy()
+	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "resourceVersion")
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+func edit(t *throwing.TableView) {
+	namespace, name := getNamespaceAndName(t)
+	r, err := lookupResource(t.GetClientSet(), t.GetResourceKind())
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	client, err := resourceClient(r, namespace)
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	live, err := client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	owned := appliedFields(live)
+	unstructured.RemoveNestedField(live.Object, "metadata", "managedFields")
+	data, err := yaml.Marshal(live.Object)
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	editObject(t, r, live, owned, string(data))
+}
+
+/*
+editObject edits an object in the editor of the user, then applies its configuration with server-side apply as a
+dry-run. The diff of the live object against what the server would store, defaults and webhook mutations included, is
+shown for review before the change is applied for real. owned are the fields axe applied before.
+*/
+func editObject(t *throwing.TableView, r apiResource, live *unstructured.Unstructured, owned map[string]interface{}, text string) {
+	edited, err := editText(t, live.GetName(), text)
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	if original, err := yaml.Marshal(live.Object); err == nil && edited == string(original) {
+		t.UpdateStatus("edit canceled, no changes made", false)
+		return
+	}
+
+	obj := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(edited), &obj); err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	if err := checkIdentity(live, &unstructured.Unstructured{Object: obj}); err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	e := &objectEdit{
+		resource: r,
+		live:     live,
+		owned:    owned,
+		text:     edited,
+		config:   appliedConfig(live, &unstructured.Unstructured{Object: obj}, owned),
+		kept:     removedFields("", live.Object, obj, owned),
+	}
+	reviewEdit(t, e, false)
+}
+
+// objectEdit is an edit of an object, with the configuration applied for it.
+type objectEdit struct {
+	resource apiResource
+	live     *unstructured.Unstructured
+	owned    map[string]interface{}
+	text     string
+	config   *unstructured.Unstructured
+	// kept are the fields removed in the editor that other managers own, server-side apply does not remove them
+	kept []string
+}
+
+// reviewEdit shows the diff of the dry-run of an edit, conflicts with other field managers can be forced.
+func reviewEdit(t *throwing.TableView, e *objectEdit, force bool) {
+	result, err := serverSideApply(t.GetClientSet(), e.resource, e.config, force, true)
+	if errors.IsConflict(err) && !force {
+		confirm(t, "force", fmt.Sprintf("The edit conflicts with other field managers:\n\n%v\n\nDo you want to take the fields over?", err), func() {
+			reviewEdit(t, e, true)
+		})
+		return
+	}
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+
+	kept := ""
+	if len(e.kept) > 0 {
+		kept = fmt.Sprintf("%s are owned by other field managers, only the fields %s applied can be removed", strings.Join(e.kept, ", "), fieldManager)
+	}
+	diff := unifiedDiff(diffView(e.live), diffView(result), "live", "edited (dry-run)")
+	if diff == "" {
+		if kept != "" {
+			t.UpdateStatus("the edit does not change the object: "+kept, true)
+			return
+		}
+		t.UpdateStatus("the edit does not change the object", false)
+		return
+	}
+	text := colorDiff(diff)
+	if kept != "" {
+		text = fmt.Sprintf("[yellow]not removed: %s[white]\n\n%s", tview.Escape(kept), text)
+	}
+	title := fmt.Sprintf("edit - (%s) as %s [y] apply [e] edit again", e.live.GetName(), fieldManager)
+	if force {
+		title = fmt.Sprintf("edit - (%s) as %s, forced [y] apply [e] edit again", e.live.GetName(), fieldManager)
+	}
+	box := showText(t, "edit", title, text)
+	box.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'y':
+			confirm(t, "apply", fmt.Sprintf("Do you want to apply the edit of %s %s?", t.GetResourceKind(), e.live.GetName()), func() {
+				if _, err := serverSideApply(t.GetClientSet(), e.resource, e.config, force, false); err != nil {
+					t.UpdateStatus(err.Error(), true)
+					return
+				}
+				t.SwitchToRootPage()
+				t.RefreshManual()
+			})
+		case 'e':
+			editObject(t, e.resource, e.live, e.owned, e.text)
+		default:
+			return event
+		}
+		return nil
+	})
+}
+
+// appliedFields returns the fields axe applied to an object before, merged from its Apply entries of managedFields.
+func appliedFields(obj *unstructured.Unstructured) map[string]interface{} {
+	entries, _, _ := unstructured.NestedSlice(obj.Object, "metadata", "managedFields")
+	owned := map[string]interface{}{}
+	for _, e := range entries {
+		entry, ok := e.(map[string]interface{})
+		if !ok || entry["manager"] != fieldManager || entry["operation"] != "Apply" {
+			continue
+		}
+		fields, ok := entry["fieldsV1"].(map[string]interface{})
+		if !ok {
+			fields, _ = entry["fields"].(map[string]interface{})
+		}
+		merged, _ := mergeFields(owned, fields).(map[string]interface{})
+		owned = merged
+	}
+	return owned
+}
+
+/*
+appliedConfig returns what axe applies for an edit: the fields it applied before, at their edited values, and the fields
+that differ from the live object. Server-side apply removes the fields a manager applied before and leaves out, so the
+fields axe owns have to be applied again, and removing one of them in the editor removes it from the object. Other
+fields are left to the managers that own them. Changed lists are applied whole.
+*/
+func appliedConfig(live, edited *unstructured.Unstructured, owned map[string]interface{}) *unstructured.Unstructured {
+	changed, _ := changedFields(live.Object, edited.Object)
+	config := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	if m, ok := mergeFields(ownedFields(edited.Object, owned), changed).(map[string]interface{}); ok {
+		config.Object = m
+	}
+	config.SetAPIVersion(live.GetAPIVersion())
+	config.SetKind(live.GetKind())
+	config.SetName(live.GetName())
+	config.SetNamespace(live.GetNamespace())
+	return config
+}
+
+/*
+checkIdentity refuses edits of what identifies the object, as kubectl edit does: applied with another apiVersion, kind,
+name or namespace, the edit would create another object rather than change this one. Fields left empty are unchanged.
+*/
+func checkIdentity(live, edited *unstructured.Unstructured) error {
+	fields := []struct {
+		name        string
+		live, value string
+	}{
+		{"apiVersion", live.GetAPIVersion(), edited.GetAPIVersion()},
+		{"kind", live.GetKind(), edited.GetKind()},
+		{"metadata.name", live.GetName(), edited.GetName()},
+		{"metadata.namespace", live.GetNamespace(), edited.GetNamespace()},
+	}
+	for _, f := range fields {
+		if f.value != "" && f.value != f.live {
+			return fmt.Errorf("%s can not be changed from %q to %q, edit another object instead", f.name, f.live, f.value)
+		}
+	}
+	return nil
+}
+
+func changedFields(live, edited interface{}) (interface{}, bool) {
+	liveMap, ok := live.(map[string]interface{})
+	editedMap, isMap := edited.(map[string]interface{})
+	if !ok || !isMap {
+		return edited, !reflect.DeepEqual(live, edited)
+	}
+	changed := map[string]interface{}{}
+	for k, v := range editedMap {
+		if sub, ok := changedFields(liveMap[k], v); ok {
+			changed[k] = sub
+		}
+	}
+	return changed, len(changed) > 0
+}
+
+/*
+ownedFields returns the part of a value found in a tree of managed fields, see fieldPaths. A field without children in
+the tree is owned whole. The key fields of list items are kept, the server needs them to match the items.
+*/
+func ownedFields(value interface{}, fields map[string]interface{}) interface{} {
+	children := map[string]interface{}{}
+	for k, v := range fields {
+		if k != "." {
+			children[k] = v
+		}
+	}
+	if len(children) == 0 {
+		return value
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		owned := map[string]interface{}{}
+		for key, sub := range children {
+			if !strings.HasPrefix(key, "f:") {
+				continue
+			}
+			// a field removed in the editor is left out, which removes it
+			if child, ok := v[key[2:]]; ok {
+				subFields, _ := sub.(map[string]interface{})
+				owned[key[2:]] = ownedFields(child, subFields)
+			}
+		}
+		return owned
+	case []interface{}:
+		var owned []interface{}
+		for i, item := range v {
+			for key, sub := range children {
+				selector, ok := listItemKey(key, i, item)
+				if !ok {
+					continue
+				}
+				subFields, _ := sub.(map[string]interface{})
+				projected := ownedFields(item, subFields)
+				if m, isMap := projected.(map[string]interface{}); isMap {
+					itemMap, _ := item.(map[string]interface{})
+					for k := range selector {
+						m[k] = itemMap[k]
+					}
+				}
+				owned = append(owned, projected)
+				break
+			}
+		}
+		return owned
+	}
+	return value
+}
+
+// listItemKey tells if the item at index i of a list is the one of a key of managed fields, with the fields of its key.
+func listItemKey(key string, i int, item interface{}) (map[string]interface{}, bool) {
+	if len(key) < 2 || key[1] != ':' {
+		return nil, false
+	}
+	value := key[2:]
+	switch key[0] {
+	case 'k':
+		selector := map[string]interface{}{}
+		itemMap, ok := item.(map[string]interface{})
+		if !ok || json.Unmarshal([]byte(value), &selector) != nil {
+			return nil, false
+		}
+		for k, v := range selector {
+			// numbers are float64 in the key and int64 in the object
+			if fmt.Sprint(itemMap[k]) != fmt.Sprint(v) {
+				return nil, false
+			}
+		}
+		return selector, true
+	case 'v':
+		var set interface{}
+		if json.Unmarshal([]byte(value), &set) != nil {
+			return nil, false
+		}
+		return nil, fmt.Sprint(set) == fmt.Sprint(item)
+	case 'i':
+		return nil, value == fmt.Sprint(i)
+	}
+	return nil, false
+}
+
+// mergeFields merges two values, maps key by key, b wins otherwise.
+func mergeFields(a, b interface{}) interface{} {
+	aMap, ok := a.(map[string]interface{})
+	bMap, isMap := b.(map[string]interface{})
+	if !ok || !isMap {
+		if b == nil {
+			return a
+		}
+		return b
+	}
+	merged := map[string]interface{}{}
+	for k, v := range aMap {
+		merged[k] = v
+	}
+	for k, v := range bMap {
+		merged[k] = mergeFields(aMap[k], v)
+	}
+	return merged
+}
+
+var (
+	// serverFields are set by the server, removing them in the editor means nothing
+	serverFields = map[string]bool{
+		".status":                     true,
+		".metadata.uid":               true,
+		".metadata.resourceVersion":   true,
+		".metadata.generation":        true,
+		".metadata.creationTimestamp": true,
+		".metadata.selfLink":          true,
+		".metadata.managedFields":     true,
+	}
+)
+
+// removedFields returns the paths of the fields removed in the editor that axe does not own, apply can not remove them.
+func removedFields(prefix string, live, edited interface{}, owned map[string]interface{}) []string {
+	liveMap, ok := live.(map[string]interface{})
+	editedMap, isMap := edited.(map[string]interface{})
+	if !ok || !isMap {
+		return nil
+	}
+	var removed []string
+	for _, k := range sortedFieldKeys(liveMap) {
+		path := prefix + "." + k
+		if serverFields[path] {
+			continue
+		}
+		sub, isOwned := owned["f:"+k]
+		subFields, _ := sub.(map[string]interface{})
+		if _, ok := editedMap[k]; !ok {
+			if !isOwned {
+				removed = append(removed, path)
+			}
+			continue
+		}
+		if isOwned && len(subFields) == 0 {
+			continue
+		}
+		removed = append(removed, removedFields(path, liveMap[k], editedMap[k], subFields)...)
+	}
+	return removed
+}
+
+func sortedFieldKeys(m map[string]interface{}) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,245 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const editLive = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+  labels:
+    app: web
+    team: a
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1
+        imagePullPolicy: IfNotPresent
+      - name: sidecar
+        image: envoy:1
+`
+
+func TestChangedFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		edited  string
+		want    string
+		changed bool
+	}{
+		{
+			name:   "unchanged",
+			edited: editLive,
+			want:   "{}",
+		},
+		{
+			name: "scalar",
+			edited: `
+metadata:
+  labels:
+    app: web
+    team: b
+spec:
+  replicas: 3
+`,
+			want:    "metadata: {labels: {team: b}}",
+			changed: true,
+		},
+		{
+			name: "list taken whole",
+			edited: `
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:2
+`,
+			want:    "spec: {template: {spec: {containers: [{name: web, image: 'nginx:2'}]}}}",
+			changed: true,
+		},
+	}
+	live := parseYAML(t, editLive)
+	for _, tt := range tests {
+		got, changed := changedFields(live, parseYAML(t, tt.edited))
+		if changed != tt.changed || !reflect.DeepEqual(got, parseYAML(t, tt.want)) {
+			t.Errorf("%s: changedFields() = %v, %v, want %v, %v", tt.name, got, changed, tt.want, tt.changed)
+		}
+	}
+}
+
+func TestOwnedFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields string
+		want   string
+	}{
+		{
+			name:   "nothing owned",
+			fields: "{}",
+			want:   editLive,
+		},
+		{
+			name:   "field",
+			fields: `{"f:spec": {"f:replicas": {}}}`,
+			want:   "spec: {replicas: 3}",
+		},
+		{
+			name:   "map owned itself",
+			fields: `{"f:metadata": {"f:labels": {".": {}}}}`,
+			want:   "metadata: {labels: {app: web, team: a}}",
+		},
+		{
+			name:   "list item by key",
+			fields: `{"f:spec": {"f:template": {"f:spec": {"f:containers": {"k:{\"name\":\"sidecar\"}": {".": {}, "f:image": {}}}}}}}`,
+			want:   "spec: {template: {spec: {containers: [{name: sidecar, image: 'envoy:1'}]}}}",
+		},
+		{
+			name:   "list item by index",
+			fields: `{"f:spec": {"f:template": {"f:spec": {"f:containers": {"i:0": {"f:imagePullPolicy": {}}}}}}}`,
+			want:   "spec: {template: {spec: {containers: [{imagePullPolicy: IfNotPresent}]}}}",
+		},
+		{
+			name:   "field missing",
+			fields: `{"f:spec": {"f:paused": {}}}`,
+			want:   "spec: {}",
+		},
+	}
+	live := parseYAML(t, editLive)
+	for _, tt := range tests {
+		got := ownedFields(live, parseYAML(t, tt.fields))
+		if !reflect.DeepEqual(got, parseYAML(t, tt.want)) {
+			t.Errorf("%s: ownedFields() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAppliedConfig(t *testing.T) {
+	owned := parseYAML(t, `{"f:spec": {"f:replicas": {}}}`)
+	tests := []struct {
+		name   string
+		edited string
+		want   string
+	}{
+		{
+			name: "fields applied before are applied again",
+			edited: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, namespace: default, labels: {app: web, team: a}}
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - {name: web, image: 'nginx:2', imagePullPolicy: IfNotPresent}
+      - {name: sidecar, image: 'envoy:1'}
+`,
+			want: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, namespace: default}
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - {name: web, image: 'nginx:2', imagePullPolicy: IfNotPresent}
+      - {name: sidecar, image: 'envoy:1'}
+`,
+		},
+		{
+			name: "field applied before removed",
+			edited: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, namespace: default, labels: {app: web, team: a}}
+spec:
+  template:
+    spec:
+      containers:
+      - {name: web, image: 'nginx:1', imagePullPolicy: IfNotPresent}
+      - {name: sidecar, image: 'envoy:1'}
+`,
+			want: `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, namespace: default}
+spec: {}
+`,
+		},
+	}
+	live := &unstructured.Unstructured{Object: parseYAML(t, editLive)}
+	for _, tt := range tests {
+		got := appliedConfig(live, &unstructured.Unstructured{Object: parseYAML(t, tt.edited)}, owned)
+		if !reflect.DeepEqual(got.Object, parseYAML(t, tt.want)) {
+			t.Errorf("%s: appliedConfig() = %v, want %v", tt.name, got.Object, tt.want)
+		}
+	}
+}
+
+func TestCheckIdentity(t *testing.T) {
+	tests := []struct {
+		name    string
+		edited  string
+		wantErr bool
+	}{
+		{
+			name:   "unchanged",
+			edited: editLive,
+		},
+		{
+			name:   "left out",
+			edited: "spec: {replicas: 2}",
+		},
+		{
+			name:    "renamed",
+			edited:  "metadata: {name: web-2}",
+			wantErr: true,
+		},
+		{
+			name:    "moved",
+			edited:  "metadata: {name: web, namespace: other}",
+			wantErr: true,
+		},
+		{
+			name:    "other kind",
+			edited:  "{apiVersion: apps/v1, kind: StatefulSet}",
+			wantErr: true,
+		},
+		{
+			name:    "other version",
+			edited:  "{apiVersion: apps/v1beta2}",
+			wantErr: true,
+		},
+	}
+	live := &unstructured.Unstructured{Object: parseYAML(t, editLive)}
+	for _, tt := range tests {
+		err := checkIdentity(live, &unstructured.Unstructured{Object: parseYAML(t, tt.edited)})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: checkIdentity() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRemovedFields(t *testing.T) {
+	owned := parseYAML(t, `{"f:metadata": {"f:labels": {"f:team": {}}}}`)
+	edited := parseYAML(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata: {name: web, namespace: default, labels: {}}
+`)
+	got := removedFields("", parseYAML(t, editLive), edited, owned)
+	want := []string{".metadata.labels.app", ".spec"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("removedFields() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,416 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/rancher/axe/throwing"
+	"github.com/rancher/axe/throwing/datafeeder"
+	"github.com/rancher/axe/throwing/types"
+	"github.com/rivo/tview"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	eventStreamKind = "eventstream"
+
+	// recentWarningAge is how old a warning can be to be counted in the WARNINGS column of the tables
+	recentWarningAge = time.Hour
+	// eventRedrawInterval bounds how often the page is redrawn while events keep coming
+	eventRedrawInterval = time.Second
+	// eventRetryInterval is the wait before listing again when the list or the watch fails
+	eventRetryInterval = 5 * time.Second
+	// eventStreamIdle is how long a stream is kept once no table asked for it, it is then stopped
+	eventStreamIdle = time.Minute
+)
+
+var (
+	// eventStreams are the running event streams by namespace, the one of all namespaces under ""
+	eventStreams     = map[string]*eventStream{}
+	eventStreamsLock sync.Mutex
+
+	eventStreamActions = []types.Action{
+		{
+			Name:        "warnings",
+			Shortcut:    "w",
+			Description: "show warnings only",
+		},
+		{
+			Name:        "namespace",
+			Shortcut:    "n",
+			Description: "filter by namespace",
+		},
+	}
+)
+
+/*
+eventStream keeps the events of a namespace, or of all namespaces, up to date with a watch, for the events page and the
+WARNINGS column of the tables of the namespace. It is started the first time a table asks for it and stopped once no
+table did for eventStreamIdle.
+*/
+type eventStream struct {
+	clientset *kubernetes.Clientset
+	namespace string
+	// used is when a table last asked for the stream, it is guarded by eventStreamsLock
+	used time.Time
+
+	lock   sync.Mutex
+	events map[k8stypes.UID]corev1.Event
+	dirty  bool
+	// err is why the events could not be listed last, they are not known until they are listed again
+	err error
+}
+
+/*
+namespaceEvents returns the event stream of the namespace, or of all namespaces if empty. A new stream lists the events
+before it is returned, so that the tables asking for it count the warnings on their first refresh.
+*/
+func namespaceEvents(clientset *kubernetes.Clientset, namespace string) *eventStream {
+	eventStreamsLock.Lock()
+	defer eventStreamsLock.Unlock()
+
+	s, ok := eventStreams[namespace]
+	if !ok {
+		s = &eventStream{
+			clientset: clientset,
+			namespace: namespace,
+			events:    map[k8stypes.UID]corev1.Event{},
+		}
+		eventStreams[namespace] = s
+		resourceVersion, _ := s.list()
+		go s.run(resourceVersion)
+	}
+	s.used = time.Now()
+	return s
+}
+
+// stopIfIdle removes the stream once no table asked for it for eventStreamIdle, it then stops.
+func (s *eventStream) stopIfIdle() bool {
+	eventStreamsLock.Lock()
+	defer eventStreamsLock.Unlock()
+
+	if time.Since(s.used) < eventStreamIdle {
+		return false
+	}
+	if eventStreams[s.namespace] == s {
+		delete(eventStreams, s.namespace)
+	}
+	return true
+}
+
+// run watches the events from the version listed, and lists them again whenever the watch ends, until it is idle.
+func (s *eventStream) run(resourceVersion string) {
+	for {
+		if resourceVersion != "" && s.watch(resourceVersion) {
+			return
+		}
+		if s.stopIfIdle() {
+			return
+		}
+		var err error
+		if resourceVersion, err = s.list(); err != nil {
+			time.Sleep(eventRetryInterval)
+		}
+	}
+}
+
+func (s *eventStream) list() (string, error) {
+	list, err := s.clientset.CoreV1().Events(s.namespace).List(metav1.ListOptions{})
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err != nil {
+		// the page shows the error once, not at every retry
+		s.dirty = s.dirty || s.err == nil
+		s.err = err
+		return "", err
+	}
+	s.err = nil
+	s.dirty = true
+	s.events = map[k8stypes.UID]corev1.Event{}
+	for _, e := range list.Items {
+		s.events[e.UID] = e
+	}
+	return list.ResourceVersion, nil
+}
+
+// watch applies the changes to the events until the watch ends, or the stream is idle, in which case it returns true.
+func (s *eventStream) watch(resourceVersion string) bool {
+	w, err := s.clientset.CoreV1().Events(s.namespace).Watch(metav1.ListOptions{ResourceVersion: resourceVersion})
+	if err != nil {
+		return false
+	}
+	defer w.Stop()
+
+	idle := time.NewTicker(eventStreamIdle / 4)
+	defer idle.Stop()
+	for {
+		select {
+		case <-idle.C:
+			if s.stopIfIdle() {
+				return true
+			}
+		case change, ok := <-w.ResultChan():
+			if !ok {
+				return false
+			}
+			e, ok := change.Object.(*corev1.Event)
+			if !ok {
+				// the watch failed, e.g. its version expired
+				return false
+			}
+			s.lock.Lock()
+			switch change.Type {
+			case watch.Added, watch.Modified:
+				s.events[e.UID] = *e
+			case watch.Deleted:
+				delete(s.events, e.UID)
+			}
+			s.dirty = true
+			s.lock.Unlock()
+		}
+	}
+}
+
+// changed tells if the events changed since last read, and reads them.
+func (s *eventStream) changed() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	dirty := s.dirty
+	s.dirty = false
+	return dirty
+}
+
+// current returns the events kept, or why they could not be listed.
+func (s *eventStream) current() ([]corev1.Event, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	var events []corev1.Event
+	for _, e := range s.events {
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+/*
+recentWarnings counts the recent warning events of every object. They are counted from the events the stream keeps, so
+that refreshing a table does not list the events again. It returns false if the events could not be listed.
+*/
+func (s *eventStream) recentWarnings() (map[k8stypes.UID]int, bool) {
+	events, err := s.current()
+	if err != nil {
+		return nil, false
+	}
+	counts := map[k8stypes.UID]int{}
+	for _, e := range events {
+		if e.Type != corev1.EventTypeWarning || time.Since(eventTime(e).Time) > recentWarningAge {
+			continue
+		}
+		// events of the events.k8s.io API leave the count empty
+		if e.Count == 0 {
+			e.Count = 1
+		}
+		counts[e.InvolvedObject.UID] += int(e.Count)
+	}
+	return counts, true
+}
+
+/*
+eventsPage is the DataSource of the events page. It reads the events from the stream of the namespace it is filtered
+on, so that refreshing the page only reads them from memory.
+*/
+type eventsPage struct {
+	clientset *kubernetes.Clientset
+
+	lock         sync.Mutex
+	warningsOnly bool
+	namespace    string
+	events       []corev1.Event
+	rows         []datafeeder.Row
+}
+
+func (p *eventsPage) stream() *eventStream {
+	p.lock.Lock()
+	namespace := p.namespace
+	p.lock.Unlock()
+	return namespaceEvents(p.clientset, namespace)
+}
+
+func (p *eventsPage) Header() datafeeder.Row {
+	return datafeeder.Row{"LAST SEEN", "TYPE", "NAMESPACE", "REASON", "OBJECT", "COUNT", "FIRST SEEN", "MESSAGE"}
+}
+
+func (p *eventsPage) Data() []datafeeder.Row {
+	return p.rows
+}
+
+func (p *eventsPage) Refresh() error {
+	stream := p.stream()
+	stream.changed()
+	events, err := stream.current()
+	if err != nil {
+		return err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var list []corev1.Event
+	for _, e := range events {
+		if p.warningsOnly && e.Type != corev1.EventTypeWarning {
+			continue
+		}
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return eventTime(list[j]).Time.Before(eventTime(list[i]).Time)
+	})
+
+	p.events = list
+	p.rows = nil
+	for _, e := range list {
+		p.rows = append(p.rows, datafeeder.Row{
+			since(eventTime(e)),
+			e.Type,
+			e.Namespace,
+			e.Reason,
+			e.InvolvedObject.Kind + "/" + e.InvolvedObject.Name,
+			fmt.Sprint(e.Count),
+			since(e.FirstTimestamp),
+			strings.Join(strings.Fields(e.Message), " "),
+		})
+	}
+	return nil
+}
+
+// changed tells if the events of the stream changed, it is also what keeps the stream running while the page is shown.
+func (p *eventsPage) changed() bool {
+	return p.stream().changed()
+}
+
+// involvedObject returns the object of the events shown with the namespace and object cells of a row.
+func (p *eventsPage) involvedObject(namespace, object string) (corev1.ObjectReference, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, e := range p.events {
+		if e.Namespace == namespace && e.InvolvedObject.Kind+"/"+e.InvolvedObject.Name == object {
+			return e.InvolvedObject, true
+		}
+	}
+	return corev1.ObjectReference{}, false
+}
+
+func (p *eventsPage) toggleWarningsOnly() {
+	p.lock.Lock()
+	p.warningsOnly = !p.warningsOnly
+	p.lock.Unlock()
+}
+
+func (p *eventsPage) setNamespace(namespace string) {
+	p.lock.Lock()
+	p.namespace = namespace
+	p.lock.Unlock()
+}
+
+func (p *eventsPage) title() string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	title := "Events"
+	if p.warningsOnly {
+		title += " - warnings"
+	}
+	if p.namespace != "" {
+		title += " - " + p.namespace
+	}
+	return title
+}
+
+func eventsEventHandler(p *eventsPage) throwing.EventHandler {
+	return func(t *throwing.TableView) func(event *tcell.EventKey) *tcell.EventKey {
+		return func(event *tcell.EventKey) *tcell.EventKey {
+			switch event.Key() {
+			case tcell.KeyEnter:
+				table := t.GetTable()
+				row, _ := table.GetSelection()
+				ref, ok := p.involvedObject(table.GetCell(row, 2).Text, table.GetCell(row, 4).Text)
+				if !ok {
+					return event
+				}
+				r, err := lookupKind(t.GetClientSet(), ref.APIVersion, ref.Kind)
+				if err != nil {
+					t.UpdateStatus(err.Error(), true)
+					return event
+				}
+				showObject(t, r.tableKind(), ref.Namespace, ref.Name)
+			case tcell.KeyEscape:
+				t.BackPage()
+			case tcell.KeyRune:
+				switch event.Rune() {
+				case 'w':
+					p.toggleWarningsOnly()
+					t.GetTable().SetTitle(p.title())
+					t.RefreshManual()
+				case 'n':
+					eventNamespaceDialog(t, p)
+				case 'r':
+					t.RefreshManual()
+				case 'q':
+					t.RootPage()
+				case '/':
+					t.ShowSearch()
+				}
+			}
+			return event
+		}
+	}
+}
+
+func eventNamespaceDialog(t *throwing.TableView, p *eventsPage) {
+	namespace := ""
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle("events - namespace")
+	form.AddInputField("Namespace", "", 30, nil, func(text string) {
+		namespace = strings.TrimSpace(text)
+	})
+	form.AddButton("filter", func() {
+		p.setNamespace(namespace)
+		t.GetTable().SetTitle(p.title())
+		t.BackPage()
+		t.RefreshManual()
+	})
+	form.AddButton("Cancel", func() {
+		t.BackPage()
+	})
+	form.SetCancelFunc(func() {
+		t.BackPage()
+	})
+	t.InsertDialog("namespace", t.GetCurrentPrimitive(), form)
+}
+
+func viewEvents(t *throwing.TableView) {
+	newtable := t.GetNestedTable(eventStreamKind)
+	if newtable == nil {
+		p := &eventsPage{clientset: t.GetClientSet()}
+		kind := types.ResourceKind{
+			Title: p.title(),
+			Kind:  eventStreamKind,
+		}
+		newtable = t.NewNestTableView(kind, p, eventStreamActions, nil, eventsEventHandler(p))
+		t.SetTableView(eventStreamKind, newtable)
+		// redraw the page as events come, at most once per interval
+		newtable.RefreshEvery(eventRedrawInterval, p.changed)
+	} else {
+		newtable.RefreshManual()
+	}
+	t.SwitchPage(eventStreamKind, newtable)
+}
@@ -0,0 +1,233 @@
+package k8s
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rancher/axe/throwing"
+	"github.com/rivo/tview"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+const (
+	defaultExecCommand = "/bin/sh"
+)
+
+func execute(t *throwing.TableView) {
+	if t.GetResourceKind() != "pods" {
+		return
+	}
+
+	namespace, name := getNamespaceAndName(t)
+	pod, err := t.GetClientSet().CoreV1().Pods(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+
+	var containers []string
+	for _, c := range pod.Spec.Containers {
+		containers = append(containers, c.Name)
+	}
+	if len(containers) == 0 {
+		t.UpdateStatus(fmt.Sprintf("pod %s has no containers", name), true)
+		return
+	}
+	container, command := containers[0], defaultExecCommand
+
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle(fmt.Sprintf("exec - (%s)", name))
+	form.AddDropDown("Container", containers, 0, func(option string, optionIndex int) {
+		container = option
+	})
+	form.AddInputField("Command", command, 30, nil, func(text string) {
+		command = text
+	})
+	form.AddButton("exec", func() {
+		t.SwitchToRootPage()
+		args := strings.Fields(command)
+		if len(args) == 0 {
+			args = []string{defaultExecCommand}
+		}
+		openSession(t, fmt.Sprintf("exec %s/%s", name, container), func(streams remotecommand.StreamOptions) (int, error) {
+			return streamExec(t, namespace, name, container, args, streams)
+		})
+	})
+	form.AddButton("Cancel", func() {
+		t.BackPage()
+	})
+	form.SetCancelFunc(func() {
+		t.BackPage()
+	})
+	t.InsertDialog("exec", t.GetCurrentPrimitive(), form)
+}
+
+func attach(t *throwing.TableView) {
+	if t.GetResourceKind() != "pods" {
+		return
+	}
+
+	namespace, name := getNamespaceAndName(t)
+	pod, err := t.GetClientSet().CoreV1().Pods(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+
+	var containers []string
+	for _, c := range pod.Spec.Containers {
+		containers = append(containers, c.Name)
+	}
+	index := 0
+
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle(fmt.Sprintf("attach - (%s)", name))
+	form.AddDropDown("Container", containers, 0, func(option string, optionIndex int) {
+		index = optionIndex
+	})
+	form.AddButton("attach", func() {
+		t.SwitchToRootPage()
+		container := pod.Spec.Containers[index]
+		openSession(t, fmt.Sprintf("attach %s/%s", name, container.Name), func(streams remotecommand.StreamOptions) (int, error) {
+			return streamAttach(t, namespace, name, container, streams)
+		})
+	})
+	form.AddButton("Cancel", func() {
+		t.BackPage()
+	})
+	form.SetCancelFunc(func() {
+		t.BackPage()
+	})
+	t.InsertDialog("attach", t.GetCurrentPrimitive(), form)
+}
+
+/*
+openSession runs a remote process in a new terminal tab. The tab is the process tty: its keys are the stdin of the
+process and its size is forwarded whenever the tab is resized. The exit code is reported once the process ends.
+*/
+func openSession(t *throwing.TableView, title string, stream func(streams remotecommand.StreamOptions) (int, error)) {
+	stdin, input := io.Pipe()
+	view := throwing.NewTerminalView(input)
+	sizes := newTerminalSizeQueue(view)
+	session := t.OpenTerminal(title, view)
+
+	go func() {
+		code, err := stream(remotecommand.StreamOptions{
+			Stdin:             stdin,
+			Stdout:            view,
+			Tty:               true,
+			TerminalSizeQueue: sizes,
+		})
+		sizes.stop()
+		input.Close()
+		if err != nil {
+			session.Finish(err.Error(), true)
+			return
+		}
+		session.Finish(fmt.Sprintf("%s exited with code %d", title, code), code != 0)
+	}()
+}
+
+func streamExec(t *throwing.TableView, namespace, name, container string, command []string, streams remotecommand.StreamOptions) (int, error) {
+	return streamPod(t, namespace, name, "exec", &corev1.PodExecOptions{
+		Container: container,
+		Command:   command,
+		Stdin:     streams.Stdin != nil,
+		Stdout:    streams.Stdout != nil,
+		Stderr:    streams.Stderr != nil,
+		TTY:       streams.Tty,
+	}, streams)
+}
+
+// streamAttach attaches to the main process of a container. Stdin and the tty are only requested if the container allocates them.
+func streamAttach(t *throwing.TableView, namespace, name string, container corev1.Container, streams remotecommand.StreamOptions) (int, error) {
+	if !container.Stdin {
+		streams.Stdin = nil
+	}
+	if !container.TTY {
+		streams.Tty = false
+		streams.TerminalSizeQueue = nil
+		streams.Stderr = streams.Stdout
+	}
+	return streamPod(t, namespace, name, "attach", &corev1.PodAttachOptions{
+		Container: container.Name,
+		Stdin:     streams.Stdin != nil,
+		Stdout:    streams.Stdout != nil,
+		Stderr:    streams.Stderr != nil,
+		TTY:       streams.Tty,
+	}, streams)
+}
+
+// streamPod opens a SPDY stream to the exec or attach subresource of a pod. A non-zero exit code of the remote process is returned as the code, not as an error.
+func streamPod(t *throwing.TableView, namespace, name, subresource string, options runtime.Object, streams remotecommand.StreamOptions) (int, error) {
+	restConfig, err := getRestConfig()
+	if err != nil {
+		return 0, err
+	}
+
+	req := t.GetClientSet().CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(name).
+		SubResource(subresource).
+		VersionedParams(options, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(restConfig, "POST", req.URL())
+	if err != nil {
+		return 0, err
+	}
+	if err := executor.Stream(streams); err != nil {
+		if exitErr, ok := err.(utilexec.ExitError); ok && exitErr.Exited() {
+			return exitErr.ExitStatus(), nil
+		}
+		return 0, err
+	}
+	return 0, nil
+}
+
+type terminalSizeQueue struct {
+	sizes chan remotecommand.TerminalSize
+	done  chan struct{}
+}
+
+func newTerminalSizeQueue(view *throwing.TerminalView) *terminalSizeQueue {
+	q := &terminalSizeQueue{
+		sizes: make(chan remotecommand.TerminalSize, 1),
+		done:  make(chan struct{}),
+	}
+	q.resize(view.Size())
+	view.SetResizedFunc(q.resize)
+	return q
+}
+
+func (q *terminalSizeQueue) resize(width, height int) {
+	size := remotecommand.TerminalSize{Width: uint16(width), Height: uint16(height)}
+	// only the latest size matters, drop a pending one that has not been consumed yet
+	select {
+	case <-q.sizes:
+	default:
+	}
+	select {
+	case q.sizes <- size:
+	case <-q.done:
+	}
+}
+
+func (q *terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	select {
+	case size := <-q.sizes:
+		return &size
+	case <-q.done:
+		return nil
+	}
+}
+
+func (q *terminalSizeQueue) stop() {
+	close(q.done)
+}
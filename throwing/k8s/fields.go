@@ -0,0 +1,212 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/rancher/axe/throwing"
+	"github.com/rancher/axe/throwing/datafeeder"
+	"github.com/rancher/axe/throwing/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	fieldsKind = "fields"
+)
+
+// fieldOwner is a field of an object with the manager that owns it, as recorded in managedFields.
+type fieldOwner struct {
+	path       string
+	manager    string
+	operation  string
+	apiVersion string
+	time       string
+}
+
+// fieldOwners is the DataSource of the ownership page of an object.
+type fieldOwners struct {
+	clientset *kubernetes.Clientset
+	resource  apiResource
+	namespace string
+	name      string
+	owners    []fieldOwner
+}
+
+func (f *fieldOwners) Header() datafeeder.Row {
+	return datafeeder.Row{"FIELD", "MANAGER", "OPERATION", "UPDATED", "API VERSION"}
+}
+
+func (f *fieldOwners) Data() []datafeeder.Row {
+	var rows []datafeeder.Row
+	for _, o := range f.owners {
+		rows = append(rows, datafeeder.Row{o.path, o.manager, o.operation, o.time, o.apiVersion})
+	}
+	return rows
+}
+
+func (f *fieldOwners) Refresh() error {
+	client, err := resourceClient(f.resource, f.namespace)
+	if err != nil {
+		return err
+	}
+	obj, err := client.Get(f.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	owners, err := managedFieldOwners(obj)
+	if err != nil {
+		return err
+	}
+	f.owners = owners
+	return nil
+}
+
+/*
+managedFieldOwners decodes the managedFields of an object, which the vendored apimachinery predates. Each entry holds
+the fields of one manager as a tree, in `fieldsV1`, or `fields` before Kubernetes 1.18.
+*/
+func managedFieldOwners(obj *unstructured.Unstructured) ([]fieldOwner, error) {
+	entries, _, err := unstructured.NestedSlice(obj.Object, "metadata", "managedFields")
+	if err != nil {
+		return nil, err
+	}
+	var owners []fieldOwner
+	for _, e := range entries {
+		entry, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		owner := fieldOwner{
+			manager:    fmt.Sprint(entry["manager"]),
+			operation:  fmt.Sprint(entry["operation"]),
+			apiVersion: fmt.Sprint(entry["apiVersion"]),
+			time:       "-",
+		}
+		if s, ok := entry["time"].(string); ok {
+			if updated, err := time.Parse(time.RFC3339, s); err == nil {
+				owner.time = since(metav1.NewTime(updated))
+			}
+		}
+		fields, ok := entry["fieldsV1"].(map[string]interface{})
+		if !ok {
+			fields, _ = entry["fields"].(map[string]interface{})
+		}
+		for _, path := range fieldPaths("", fields) {
+			o := owner
+			o.path = path
+			owners = append(owners, o)
+		}
+	}
+	sort.SliceStable(owners, func(i, j int) bool {
+		return owners[i].path < owners[j].path
+	})
+	return owners, nil
+}
+
+/*
+fieldPaths flattens a tree of managed fields into the paths of its leaves. Keys are `f:<name>` for fields, `k:<json>`
+for list items by key, `v:<json>` for set values and `i:<index>` for list items by index; `.` marks that a node is
+owned itself.
+*/
+func fieldPaths(prefix string, fields map[string]interface{}) []string {
+	var paths []string
+	for key, value := range fields {
+		if key == "." {
+			continue
+		}
+		path := prefix + fieldPathElement(key)
+		children, _ := value.(map[string]interface{})
+		if len(children) == 0 {
+			paths = append(paths, path)
+			continue
+		}
+		if _, ok := children["."]; ok {
+			paths = append(paths, path)
+		}
+		paths = append(paths, fieldPaths(path, children)...)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func fieldPathElement(key string) string {
+	if len(key) < 2 || key[1] != ':' {
+		return "." + key
+	}
+	value := key[2:]
+	switch key[0] {
+	case 'f':
+		return "." + value
+	case 'k':
+		selector := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(value), &selector); err != nil {
+			return "[" + value + "]"
+		}
+		var parts []string
+		for k, v := range selector {
+			parts = append(parts, fmt.Sprintf("%s=%v", k, v))
+		}
+		sort.Strings(parts)
+		return "[" + strings.Join(parts, ",") + "]"
+	case 'v':
+		return "[=" + value + "]"
+	case 'i':
+		return "[" + value + "]"
+	}
+	return "." + key
+}
+
+func fieldsEventHandler(t *throwing.TableView) func(event *tcell.EventKey) *tcell.EventKey {
+	return func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape:
+			t.BackPage()
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'r':
+				t.RefreshManual()
+			case 'q':
+				t.RootPage()
+			case '/':
+				t.ShowSearch()
+			}
+		}
+		return event
+	}
+}
+
+// viewFieldOwners opens the page showing which field manager owns each field of the object of the current row.
+func viewFieldOwners(t *throwing.TableView) {
+	namespace, name := getNamespaceAndName(t)
+	r, err := lookupResource(t.GetClientSet(), t.GetResourceKind())
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+
+	page := fmt.Sprintf("%s@%s/%s/%s", fieldsKind, r.tableKind(), namespace, name)
+	newtable := t.GetNestedTable(page)
+	if newtable == nil {
+		owners := &fieldOwners{
+			clientset: t.GetClientSet(),
+			resource:  r,
+			namespace: namespace,
+			name:      name,
+		}
+		kind := types.ResourceKind{
+			Title: fmt.Sprintf("field owners - %s/%s", r.kind, name),
+			Kind:  fieldsKind,
+		}
+		newtable = t.NewNestTableView(kind, owners, nil, nil, fieldsEventHandler)
+		t.SetTableView(page, newtable)
+	} else {
+		newtable.RefreshManual()
+	}
+	t.SwitchPage(page, newtable)
+}
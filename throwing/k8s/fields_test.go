@@ -0,0 +1,54 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldPaths(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields string
+		want   []string
+	}{
+		{
+			name:   "fields",
+			fields: `{"f:spec": {"f:replicas": {}, "f:paused": {}}}`,
+			want:   []string{".spec.paused", ".spec.replicas"},
+		},
+		{
+			name:   "node owned itself",
+			fields: `{"f:metadata": {"f:labels": {".": {}, "f:app": {}}}}`,
+			want:   []string{".metadata.labels", ".metadata.labels.app"},
+		},
+		{
+			name:   "list item by key",
+			fields: `{"f:containers": {"k:{\"name\":\"web\",\"port\":80}": {".": {}, "f:image": {}}}}`,
+			want:   []string{".containers[name=web,port=80]", ".containers[name=web,port=80].image"},
+		},
+		{
+			name:   "invalid key",
+			fields: `{"f:containers": {"k:{web": {}}}`,
+			want:   []string{".containers[{web]"},
+		},
+		{
+			name:   "set value and index",
+			fields: `{"f:finalizers": {"v:\"a\"": {}}, "f:args": {"i:0": {}}}`,
+			want:   []string{`.args[0]`, `.finalizers[="a"]`},
+		},
+		{
+			name:   "unknown prefix",
+			fields: `{"x": {}}`,
+			want:   []string{".x"},
+		},
+		{
+			name:   "empty",
+			fields: `{}`,
+		},
+	}
+	for _, tt := range tests {
+		if got := fieldPaths("", parseYAML(t, tt.fields)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: fieldPaths() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
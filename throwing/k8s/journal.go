@@ -0,0 +1,316 @@
+package k8s
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/ghodss/yaml"
+	"github.com/rancher/axe/throwing"
+	"github.com/rancher/axe/throwing/datafeeder"
+	"github.com/rancher/axe/throwing/types"
+	"github.com/rivo/tview"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	journalKind = "deleted"
+
+	// journalSize is how many deleted objects are kept, the oldest are dropped first
+	journalSize = 100
+	// journalTimeFormat starts the file names, so that they sort by time
+	journalTimeFormat = "20060102-150405.000000000"
+	// redactedAnnotation marks the secrets of the journal whose values were left out, it is dropped when they are created
+	redactedAnnotation = "axe.rancher.io/redacted"
+)
+
+var (
+	journalResourceKind = types.ResourceKind{
+		Title: "Recently deleted",
+		Kind:  journalKind,
+	}
+
+	journalActions = []types.Action{
+		{
+			Name:        "view",
+			Shortcut:    "Enter",
+			Description: "view the manifest",
+		},
+		{
+			Name:        "recreate",
+			Shortcut:    "c",
+			Description: "create the object again",
+		},
+	}
+
+	// cleanedFields are dropped from the manifests of the journal, the server sets them again on create
+	cleanedFields = [][]string{
+		{"status"},
+		{"metadata", "uid"},
+		{"metadata", "resourceVersion"},
+		{"metadata", "managedFields"},
+		{"metadata", "creationTimestamp"},
+		{"metadata", "selfLink"},
+		{"metadata", "generation"},
+		{"metadata", "deletionTimestamp"},
+		{"metadata", "deletionGracePeriodSeconds"},
+		// the owners are deleted along with their objects, or get a new uid when created again
+		{"metadata", "ownerReferences"},
+	}
+
+	// journalSecrets keeps the values of deleted secrets in the journal, in plaintext, set with --journal-secrets
+	journalSecrets = false
+)
+
+// journalDir is where the manifests of the deleted objects are kept, one file each.
+func journalDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".axe", "deleted")
+}
+
+// cleanManifest returns the object without what belongs to the live object, so that it can be created again.
+func cleanManifest(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	cleaned := obj.DeepCopy()
+	for _, field := range cleanedFields {
+		unstructured.RemoveNestedField(cleaned.Object, field...)
+	}
+	return cleaned
+}
+
+/*
+redactSecret empties the values of a secret and marks it redacted, its keys, type, labels and annotations are kept so
+that it can be created again and filled in.
+*/
+func redactSecret(obj *unstructured.Unstructured) {
+	data, _, _ := unstructured.NestedMap(obj.Object, "data")
+	for key := range data {
+		data[key] = ""
+	}
+	if len(data) > 0 {
+		unstructured.SetNestedMap(obj.Object, data, "data")
+	}
+	unstructured.RemoveNestedField(obj.Object, "stringData")
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[redactedAnnotation] = "true"
+	obj.SetAnnotations(annotations)
+}
+
+/*
+journalDeleted saves the manifest of an object about to be deleted, and drops the oldest ones past the journal size. It
+returns the file written, to be removed if the deletion fails. The values of secrets are only saved if asked to, they
+would be written in plaintext.
+*/
+func journalDeleted(obj *unstructured.Unstructured) (string, error) {
+	dir := journalDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	cleaned := cleanManifest(obj)
+	if cleaned.GetKind() == "Secret" && !journalSecrets {
+		redactSecret(cleaned)
+	}
+	data, err := yaml.Marshal(cleaned.Object)
+	if err != nil {
+		return "", err
+	}
+	name := strings.Join([]string{time.Now().Format(journalTimeFormat), obj.GetKind(), obj.GetNamespace(), obj.GetName()}, "_")
+	file := filepath.Join(dir, name+".yaml")
+	if err := ioutil.WriteFile(file, data, 0600); err != nil {
+		return "", err
+	}
+
+	files, err := journalFiles()
+	if err != nil {
+		return file, err
+	}
+	for i := journalSize; i < len(files); i++ {
+		os.Remove(files[i])
+	}
+	return file, nil
+}
+
+// journalFiles returns the files of the journal, the most recent first.
+func journalFiles() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(journalDir(), "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+	return files, nil
+}
+
+// journalEntry is a deleted object of the journal.
+type journalEntry struct {
+	file    string
+	deleted time.Time
+	object  *unstructured.Unstructured
+}
+
+func readJournalEntry(file string) (journalEntry, error) {
+	entry := journalEntry{file: file}
+	info, err := os.Stat(file)
+	if err != nil {
+		return entry, err
+	}
+	entry.deleted = info.ModTime()
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return entry, err
+	}
+	object := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &object); err != nil {
+		return entry, err
+	}
+	entry.object = &unstructured.Unstructured{Object: object}
+	return entry, nil
+}
+
+func (e journalEntry) redacted() bool {
+	return e.object.GetAnnotations()[redactedAnnotation] == "true"
+}
+
+// journal is the DataSource of the recently deleted page.
+type journal struct {
+	clientset *kubernetes.Clientset
+	entries   []journalEntry
+}
+
+func (j *journal) Header() datafeeder.Row {
+	return datafeeder.Row{"DELETED", "KIND", "NAMESPACE", "NAME"}
+}
+
+func (j *journal) Data() []datafeeder.Row {
+	var rows []datafeeder.Row
+	for _, e := range j.entries {
+		rows = append(rows, datafeeder.Row{
+			since(metav1.NewTime(e.deleted)),
+			e.object.GetKind(),
+			e.object.GetNamespace(),
+			e.object.GetName(),
+		})
+	}
+	return rows
+}
+
+func (j *journal) Refresh() error {
+	files, err := journalFiles()
+	if err != nil {
+		return err
+	}
+	j.entries = nil
+	for _, file := range files {
+		// skip what is not a manifest rather than hiding the whole journal
+		if entry, err := readJournalEntry(file); err == nil {
+			j.entries = append(j.entries, entry)
+		}
+	}
+	return nil
+}
+
+// entry returns the most recent entry of the object of a row.
+func (j *journal) entry(kind, namespace, name string) (journalEntry, bool) {
+	for _, e := range j.entries {
+		if e.object.GetKind() == kind && e.object.GetNamespace() == namespace && e.object.GetName() == name {
+			return e, true
+		}
+	}
+	return journalEntry{}, false
+}
+
+// recreate creates a deleted object again and drops it from the journal.
+func (j *journal) recreate(e journalEntry) error {
+	r, err := lookupKind(j.clientset, e.object.GetAPIVersion(), e.object.GetKind())
+	if err != nil {
+		return err
+	}
+	client, err := resourceClient(r, e.object.GetNamespace())
+	if err != nil {
+		return err
+	}
+	obj := e.object.DeepCopy()
+	if annotations := obj.GetAnnotations(); annotations[redactedAnnotation] != "" {
+		delete(annotations, redactedAnnotation)
+		obj.SetAnnotations(annotations)
+	}
+	if _, err := client.Create(obj, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+	return os.Remove(e.file)
+}
+
+func journalEventHandler(j *journal) throwing.EventHandler {
+	return func(t *throwing.TableView) func(event *tcell.EventKey) *tcell.EventKey {
+		return func(event *tcell.EventKey) *tcell.EventKey {
+			table := t.GetTable()
+			row, _ := table.GetSelection()
+			e, selected := j.entry(table.GetCell(row, 1).Text, table.GetCell(row, 2).Text, table.GetCell(row, 3).Text)
+			switch event.Key() {
+			case tcell.KeyEnter:
+				if !selected {
+					return event
+				}
+				data, err := ioutil.ReadFile(e.file)
+				if err != nil {
+					t.UpdateStatus(err.Error(), true)
+					return event
+				}
+				showText(t, "manifest", fmt.Sprintf("deleted - (%s)", e.object.GetName()), tview.Escape(string(data)))
+			case tcell.KeyEscape:
+				t.BackPage()
+			case tcell.KeyRune:
+				switch event.Rune() {
+				case 'c':
+					if !selected {
+						return event
+					}
+					if e.redacted() {
+						text := fmt.Sprintf("The values of secret %s were not kept, see --journal-secrets. Do you want to create it with empty values, to fill them in with S?", e.object.GetName())
+						confirm(t, "create", text, func() {
+							t.BackPage()
+							recreateEntry(t, j, e)
+						})
+						return event
+					}
+					recreateEntry(t, j, e)
+				case 'r':
+					t.RefreshManual()
+				case 'q':
+					t.RootPage()
+				case '/':
+					t.ShowSearch()
+				}
+			}
+			return event
+		}
+	}
+}
+
+func recreateEntry(t *throwing.TableView, j *journal, e journalEntry) {
+	if err := j.recreate(e); err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	t.RefreshManual()
+	t.UpdateStatus(fmt.Sprintf("%s %s created", e.object.GetKind(), e.object.GetName()), false)
+}
+
+func viewJournal(t *throwing.TableView) {
+	newtable := t.GetNestedTable(journalKind)
+	if newtable == nil {
+		j := &journal{clientset: t.GetClientSet()}
+		newtable = t.NewNestTableView(journalResourceKind, j, journalActions, nil, journalEventHandler(j))
+		t.SetTableView(journalKind, newtable)
+	} else {
+		newtable.RefreshManual()
+	}
+	t.SwitchPage(journalKind, newtable)
+}
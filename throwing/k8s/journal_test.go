@@ -0,0 +1,84 @@
+package k8s
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCleanManifest(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: parseYAML(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+  namespace: default
+  uid: 1234
+  resourceVersion: "5"
+  creationTimestamp: "2020-01-01T00:00:00Z"
+  labels: {app: web}
+  ownerReferences:
+  - {apiVersion: apps/v1, kind: Deployment, name: web, uid: 42}
+data: {key: value}
+`)}
+	want := parseYAML(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: a, namespace: default, labels: {app: web}}
+data: {key: value}
+`)
+	if got := cleanManifest(obj); !reflect.DeepEqual(got.Object, want) {
+		t.Errorf("cleanManifest() = %v, want %v", got.Object, want)
+	}
+}
+
+func TestJournalSecrets(t *testing.T) {
+	home, err := ioutil.TempDir("", "axe-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+	defer func() {
+		journalSecrets = false
+	}()
+
+	secret := &unstructured.Unstructured{Object: parseYAML(t, "{apiVersion: v1, kind: Secret, metadata: {name: s}, type: Opaque, data: {key: dmFsdWU=}, stringData: {other: value}}")}
+	tests := []struct {
+		journalSecrets bool
+		want           string
+	}{
+		{
+			journalSecrets: false,
+			want:           "{apiVersion: v1, kind: Secret, metadata: {name: s, annotations: {axe.rancher.io/redacted: 'true'}}, type: Opaque, data: {key: ''}}",
+		},
+		{
+			journalSecrets: true,
+			want:           "{apiVersion: v1, kind: Secret, metadata: {name: s}, type: Opaque, data: {key: dmFsdWU=}, stringData: {other: value}}",
+		},
+	}
+	for _, tt := range tests {
+		journalSecrets = tt.journalSecrets
+		file, err := journalDeleted(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry, err := readJournalEntry(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(entry.object.Object, parseYAML(t, tt.want)) {
+			t.Errorf("journalSecrets %v: journaled %v, want %v", tt.journalSecrets, entry.object.Object, tt.want)
+		}
+		if entry.redacted() == tt.journalSecrets {
+			t.Errorf("journalSecrets %v: redacted() = %v", tt.journalSecrets, entry.redacted())
+		}
+	}
+	if _, ok := secret.Object["stringData"]; !ok {
+		t.Errorf("journalDeleted() changed the object deleted")
+	}
+}
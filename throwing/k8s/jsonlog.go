@@ -0,0 +1,323 @@
+package k8s
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rancher/norman/pkg/kv"
+	"github.com/rancher/norman/types/convert"
+	"github.com/rivo/tview"
+)
+
+const (
+	maxLogLines     = 5000
+	maxColumnWidth  = 40
+	logFieldsLabel  = "fields (comma separated): "
+	logFiltersLabel = "filter (key=value ...): "
+)
+
+var (
+	timeKeys  = []string{"time", "ts", "timestamp", "@timestamp"}
+	levelKeys = []string{"level", "lvl", "severity"}
+	msgKeys   = []string{"msg", "message"}
+
+	levelColors = map[string]string{
+		"trace":   "gray",
+		"debug":   "gray",
+		"info":    "green",
+		"warn":    "yellow",
+		"warning": "yellow",
+		"error":   "red",
+		"fatal":   "red",
+		"panic":   "red",
+	}
+)
+
+/*
+logFormatter sits between the log stream and the log box. Lines that are a JSON
+object are rendered as `time level msg key=value...`, or as columns when fields
+are projected. Every raw line is kept so that changing the projection or the
+filters re-renders what has already been received.
+*/
+type logFormatter struct {
+	lock    sync.Mutex
+	out     io.Writer
+	lines   []string
+	partial []byte
+	fields  []string
+	filters map[string]string
+	widths  map[string]int
+}
+
+func newLogFormatter(out io.Writer) *logFormatter {
+	return &logFormatter{
+		out:     out,
+		filters: map[string]string{},
+		widths:  map[string]int{},
+	}
+}
+
+func (l *logFormatter) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	b := &bytes.Buffer{}
+	data := append(l.partial, p...)
+	l.partial = nil
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(data[:i])
+		data = data[i+1:]
+
+		l.lines = append(l.lines, line)
+		if len(l.lines) > maxLogLines {
+			l.lines = l.lines[len(l.lines)-maxLogLines:]
+		}
+		if err := l.writeLine(b, line); err != nil {
+			return 0, err
+		}
+	}
+	l.partial = append([]byte(nil), data...)
+	if b.Len() == 0 {
+		return len(p), nil
+	}
+	if _, err := l.out.Write(b.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// SetFields parses a comma separated list of fields to project as columns. An empty list renders every field.
+func (l *logFormatter) SetFields(fields string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.fields = nil
+	l.widths = map[string]int{}
+	for _, f := range strings.Split(fields, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			l.fields = append(l.fields, f)
+		}
+	}
+}
+
+// SetFilters parses space separated key=value pairs. Only JSON lines matching every pair are shown.
+func (l *logFormatter) SetFilters(filters string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.filters = map[string]string{}
+	for _, f := range strings.Fields(filters) {
+		key, value := kv.Split(f, "=")
+		l.filters[key] = value
+	}
+}
+
+func (l *logFormatter) Fields() string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return strings.Join(l.fields, ",")
+}
+
+func (l *logFormatter) Filters() string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var filters []string
+	for k, v := range l.filters {
+		filters = append(filters, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(filters)
+	return strings.Join(filters, " ")
+}
+
+// Render writes every buffered line again, applying the current projection and filters.
+// The output is flushed in a single write so the log box only redraws once.
+func (l *logFormatter) Render() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	b := &bytes.Buffer{}
+	if len(l.fields) > 0 {
+		// measure every buffered line first so that the columns line up from the top
+		for _, line := range l.lines {
+			if entry, ok := parseJSONLine(line); ok && l.match(entry) {
+				l.columns(entry)
+			}
+		}
+		fmt.Fprintln(b, l.header())
+	}
+	for _, line := range l.lines {
+		if err := l.writeLine(b, line); err != nil {
+			return err
+		}
+	}
+	_, err := l.out.Write(b.Bytes())
+	return err
+}
+
+func (l *logFormatter) writeLine(w io.Writer, line string) error {
+	entry, ok := parseJSONLine(line)
+	if !ok {
+		if len(l.filters) > 0 {
+			return nil
+		}
+		_, err := fmt.Fprintln(w, tview.TranslateANSI(line))
+		return err
+	}
+	if !l.match(entry) {
+		return nil
+	}
+	if len(l.fields) > 0 {
+		_, err := fmt.Fprintln(w, l.columns(entry))
+		return err
+	}
+	_, err := fmt.Fprintln(w, formatJSONEntry(entry))
+	return err
+}
+
+func (l *logFormatter) match(entry map[string]interface{}) bool {
+	for k, v := range l.filters {
+		value, ok := lookupField(entry, k)
+		if !ok || convert.ToString(value) != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *logFormatter) header() string {
+	var cols []string
+	for _, f := range l.fields {
+		cols = append(cols, "[::b]"+pad(tview.Escape(strings.ToUpper(f)), l.width(f, len(f)))+"[::-]")
+	}
+	return strings.Join(cols, " ")
+}
+
+func (l *logFormatter) columns(entry map[string]interface{}) string {
+	var cols []string
+	for _, f := range l.fields {
+		value, _ := lookupField(entry, f)
+		s := truncate(convert.ToString(value), maxColumnWidth)
+		cols = append(cols, pad(tview.Escape(s), l.width(f, len([]rune(s)))))
+	}
+	return strings.Join(cols, " ")
+}
+
+// width keeps track of the widest value seen for a field so that columns stay aligned.
+func (l *logFormatter) width(field string, n int) int {
+	if n > l.widths[field] {
+		l.widths[field] = n
+	}
+	return l.widths[field]
+}
+
+func parseJSONLine(line string) (map[string]interface{}, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil, false
+	}
+	entry := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(trimmed), &entry); err != nil {
+		return nil, false
+	}
+	return entry, true
+}
+
+func formatJSONEntry(entry map[string]interface{}) string {
+	used := map[string]bool{}
+
+	var parts []string
+	if t, ok := pickField(entry, timeKeys, used); ok {
+		parts = append(parts, "[gray]"+tview.Escape(t)+"[white]")
+	}
+	if level, ok := pickField(entry, levelKeys, used); ok {
+		color, ok := levelColors[strings.ToLower(level)]
+		if !ok {
+			color = "white"
+		}
+		parts = append(parts, fmt.Sprintf("[%s]%s[white]", color, tview.Escape(strings.ToUpper(level))))
+	}
+	if msg, ok := pickField(entry, msgKeys, used); ok {
+		parts = append(parts, tview.Escape(msg))
+	}
+
+	var keys []string
+	for k := range entry {
+		if !used[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("[blue]%s[white]=%s", tview.Escape(k), tview.Escape(valueString(entry[k]))))
+	}
+	return strings.Join(parts, " ")
+}
+
+// pickField returns the first of the well-known keys present in the entry and marks it as used.
+func pickField(entry map[string]interface{}, keys []string, used map[string]bool) (string, bool) {
+	for _, k := range keys {
+		if v, ok := entry[k]; ok {
+			used[k] = true
+			return convert.ToString(v), true
+		}
+	}
+	return "", false
+}
+
+// lookupField resolves a field by its exact key first, then as a dotted path into nested objects.
+func lookupField(entry map[string]interface{}, field string) (interface{}, bool) {
+	if v, ok := entry[field]; ok {
+		return v, true
+	}
+	var current interface{} = entry
+	for _, part := range strings.Split(field, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func valueString(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return convert.ToString(v)
+		}
+		return string(data)
+	}
+	s := convert.ToString(v)
+	if strings.ContainsAny(s, " \t") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
+
+func pad(s string, width int) string {
+	if n := tview.TaggedStringWidth(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+func truncate(s string, width int) string {
+	if r := []rune(s); len(r) > width {
+		return string(r[:width-1]) + "…"
+	}
+	return s
+}
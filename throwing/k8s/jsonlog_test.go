@@ -0,0 +1,130 @@
+package k8s
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFormatJSONEntry(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{
+			name: "well-known keys first",
+			line: `{"msg": "started", "level": "info", "ts": "12:00", "port": 80}`,
+			want: "[gray]12:00[white] [green]INFO[white] started [blue]port[white]=80",
+		},
+		{
+			name: "unknown level",
+			line: `{"severity": "notice", "message": "hi"}`,
+			want: "[white]NOTICE[white] hi",
+		},
+		{
+			name: "nested values and spaces",
+			line: `{"msg": "x", "user": {"id": 1}, "path": "a b"}`,
+			want: `x [blue]path[white]="a b" [blue]user[white]={"id":1}`,
+		},
+		{
+			name: "tags escaped",
+			line: `{"msg": "[red]"}`,
+			want: "[red[]",
+		},
+	}
+	for _, tt := range tests {
+		entry, ok := parseJSONLine(tt.line)
+		if !ok {
+			t.Errorf("%s: parseJSONLine() failed", tt.name)
+			continue
+		}
+		if got := formatJSONEntry(entry); got != tt.want {
+			t.Errorf("%s: formatJSONEntry() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseJSONLine(t *testing.T) {
+	tests := []struct {
+		line string
+		ok   bool
+	}{
+		{line: `{"msg": "a"}`, ok: true},
+		{line: `  {"msg": "a"}  `, ok: true},
+		{line: `plain text`},
+		{line: `{not json`},
+		{line: `["a"]`},
+	}
+	for _, tt := range tests {
+		if _, ok := parseJSONLine(tt.line); ok != tt.ok {
+			t.Errorf("parseJSONLine(%q) = %v, want %v", tt.line, ok, tt.ok)
+		}
+	}
+}
+
+func TestLogFormatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  string
+		filters string
+		writes  []string
+		want    string
+	}{
+		{
+			name:   "lines split across writes",
+			writes: []string{`{"msg": "a"`, "}\nplain\n", "partial"},
+			want:   "a\nplain\n",
+		},
+		{
+			name:    "filters hide plain lines and other values",
+			filters: "level=error",
+			writes:  []string{"{\"level\": \"error\", \"msg\": \"a\"}\n{\"level\": \"info\", \"msg\": \"b\"}\nplain\n"},
+			want:    "[red]ERROR[white] a\n",
+		},
+		{
+			name:    "filter on a nested field",
+			filters: "req.method=GET",
+			writes:  []string{"{\"msg\": \"a\", \"req\": {\"method\": \"GET\"}}\n{\"msg\": \"b\", \"req\": {\"method\": \"POST\"}}\n"},
+			want:    "a [blue]req[white]={\"method\":\"GET\"}\n",
+		},
+		{
+			name:   "projected fields",
+			fields: "level, msg",
+			writes: []string{"{\"level\": \"info\", \"msg\": \"a\"}\n{\"level\": \"warn\", \"msg\": \"bb\"}\n"},
+			want:   "info a\nwarn bb\n",
+		},
+	}
+	for _, tt := range tests {
+		out := &bytes.Buffer{}
+		l := newLogFormatter(out)
+		l.SetFields(tt.fields)
+		l.SetFilters(tt.filters)
+		for _, w := range tt.writes {
+			if _, err := l.Write([]byte(w)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := out.String(); got != tt.want {
+			t.Errorf("%s: output = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLogFormatterRender(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := newLogFormatter(out)
+	l.Write([]byte("{\"level\": \"info\", \"msg\": \"a\"}\n{\"level\": \"warning\", \"msg\": \"b\"}\n"))
+
+	out.Reset()
+	l.SetFields("level,msg")
+	if err := l.Render(); err != nil {
+		t.Fatal(err)
+	}
+	want := "[::b]LEVEL  [::-] [::b]MSG[::-]\ninfo    a  \nwarning b  \n"
+	if got := out.String(); got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+	if l.Fields() != "level,msg" {
+		t.Errorf("Fields() = %q", l.Fields())
+	}
+}
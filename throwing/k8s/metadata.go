@@ -0,0 +1,313 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rancher/axe/throwing"
+	"github.com/rivo/tview"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// lastAppliedAnnotation is left out of the annotation editor, it is a whole manifest
+	lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+	// metadataNewRows is the number of empty rows of the editor to add entries with
+	metadataNewRows    = 3
+	metadataFieldWidth = 60
+)
+
+// metadataField is what an editor dialog edits: labels, annotations or taints.
+type metadataField string
+
+const (
+	labelsField      metadataField = "labels"
+	annotationsField metadataField = "annotations"
+	taintsField      metadataField = "taints"
+)
+
+/*
+metadataChange is the change made in an editor dialog, relative to the object it was opened on, so that it can be
+applied as well to other objects. Entries are keyed by label or annotation key, or by key and effect for taints.
+*/
+type metadataChange struct {
+	field  metadataField
+	set    map[string]string
+	remove []string
+}
+
+func (c metadataChange) empty() bool {
+	return len(c.set) == 0 && len(c.remove) == 0
+}
+
+func (c metadataChange) String() string {
+	var parts []string
+	for _, k := range sortedKeys(c.set) {
+		parts = append(parts, "set "+formatEntry(c.field, k, c.set[k]))
+	}
+	for _, k := range c.remove {
+		parts = append(parts, "remove "+k)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func editLabels(t *throwing.TableView) {
+	editMetadata(t, labelsField)
+}
+
+func editAnnotations(t *throwing.TableView) {
+	editMetadata(t, annotationsField)
+}
+
+func editTaints(t *throwing.TableView) {
+	if t.GetResourceKind() != "nodes" {
+		t.UpdateStatus("only nodes have taints", true)
+		return
+	}
+	editMetadata(t, taintsField)
+}
+
+/*
+editMetadata opens a dialog with the entries of the object of the current row, one `key=value` row each, `key=value:Effect`
+for taints. Clearing a row removes the entry. The change is applied to every selected object.
+*/
+func editMetadata(t *throwing.TableView, field metadataField) {
+	objects := selectedObjects(t)
+	current, err := readMetadata(t, field, objects[0])
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+
+	form := tview.NewForm()
+	title := fmt.Sprintf("%s - (%s)", field, objects[0].name)
+	if len(objects) > 1 {
+		title = fmt.Sprintf("%s - (%d objects)", field, len(objects))
+	}
+	form.SetBorder(true).SetTitle(title)
+	var rows []*tview.InputField
+	addRow := func(text string) {
+		row := tview.NewInputField().SetFieldWidth(metadataFieldWidth).SetText(text)
+		rows = append(rows, row)
+		form.AddFormItem(row)
+	}
+	for _, k := range sortedKeys(current) {
+		addRow(formatEntry(field, k, current[k]))
+	}
+	for i := 0; i < metadataNewRows; i++ {
+		addRow("")
+	}
+	form.AddButton("apply", func() {
+		change, err := diffMetadata(field, current, rows)
+		if err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		if change.empty() {
+			t.BackPage()
+			return
+		}
+		confirm(t, "apply", fmt.Sprintf("Do you want to %s on %s?", change, describeObjects(t, objects)), func() {
+			applyToObjects(t, string(field), objects, func(o objectRef) error {
+				return patchMetadata(t, o, change)
+			})
+		})
+	})
+	form.AddButton("add", func() {
+		addRow("")
+	})
+	form.AddButton("Cancel", func() {
+		t.BackPage()
+	})
+	form.SetCancelFunc(func() {
+		t.BackPage()
+	})
+	t.InsertDialog(string(field), t.GetCurrentPrimitive(), form)
+}
+
+// readMetadata returns the entries of an object, taints are keyed by `key:Effect` with their value.
+func readMetadata(t *throwing.TableView, field metadataField, o objectRef) (map[string]string, error) {
+	if field == taintsField {
+		node, err := t.GetClientSet().CoreV1().Nodes().Get(o.name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return taintEntries(node.Spec.Taints), nil
+	}
+
+	r, err := lookupResource(t.GetClientSet(), t.GetResourceKind())
+	if err != nil {
+		return nil, err
+	}
+	client, err := resourceClient(r, o.namespace)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := client.Get(o.name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if field == labelsField {
+		return obj.GetLabels(), nil
+	}
+	annotations := map[string]string{}
+	for k, v := range obj.GetAnnotations() {
+		if k != lastAppliedAnnotation {
+			annotations[k] = v
+		}
+	}
+	return annotations, nil
+}
+
+// diffMetadata parses the rows of the dialog and returns what changed from the current entries.
+func diffMetadata(field metadataField, current map[string]string, rows []*tview.InputField) (metadataChange, error) {
+	change := metadataChange{field: field, set: map[string]string{}}
+	edited := map[string]bool{}
+	for _, row := range rows {
+		text := strings.TrimSpace(row.GetText())
+		if text == "" {
+			continue
+		}
+		key, value, err := parseEntry(field, text)
+		if err != nil {
+			return change, err
+		}
+		edited[key] = true
+		if old, ok := current[key]; !ok || old != value {
+			change.set[key] = value
+		}
+	}
+	for _, k := range sortedKeys(current) {
+		if !edited[k] {
+			change.remove = append(change.remove, k)
+		}
+	}
+	return change, nil
+}
+
+// parseEntry parses `key=value`, or `key=value:Effect` for taints, where the value is optional.
+func parseEntry(field metadataField, text string) (string, string, error) {
+	if field != taintsField {
+		i := strings.Index(text, "=")
+		if i < 0 {
+			return text, "", nil
+		}
+		return text[:i], text[i+1:], nil
+	}
+
+	i := strings.LastIndex(text, ":")
+	if i < 0 {
+		return "", "", fmt.Errorf("taint %q has no effect, expected key=value:Effect", text)
+	}
+	effect := corev1.TaintEffect(text[i+1:])
+	switch effect {
+	case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+	default:
+		return "", "", fmt.Errorf("taint %q has an invalid effect %q", text, effect)
+	}
+	key, value := text[:i], ""
+	if j := strings.Index(key, "="); j >= 0 {
+		key, value = key[:j], key[j+1:]
+	}
+	return key + ":" + string(effect), value, nil
+}
+
+func formatEntry(field metadataField, key, value string) string {
+	if field != taintsField {
+		return key + "=" + value
+	}
+	i := strings.LastIndex(key, ":")
+	if value == "" {
+		return key
+	}
+	return key[:i] + "=" + value + key[i:]
+}
+
+func taintEntries(taints []corev1.Taint) map[string]string {
+	entries := map[string]string{}
+	for _, taint := range taints {
+		entries[taint.Key+":"+string(taint.Effect)] = taint.Value
+	}
+	return entries
+}
+
+/*
+patchMetadata applies a change to an object with a JSON merge patch. Labels and annotations are patched key by key, the
+taints of a node are a list that is replaced as a whole, so the patch is guarded by the resource version read.
+*/
+func patchMetadata(t *throwing.TableView, o objectRef, change metadataChange) error {
+	if change.field == taintsField {
+		node, err := t.GetClientSet().CoreV1().Nodes().Get(o.name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		taints := []corev1.Taint{}
+		for _, taint := range node.Spec.Taints {
+			k := taint.Key + ":" + string(taint.Effect)
+			if _, ok := change.set[k]; ok || contains(change.remove, k) {
+				continue
+			}
+			taints = append(taints, taint)
+		}
+		for _, k := range sortedKeys(change.set) {
+			i := strings.LastIndex(k, ":")
+			taints = append(taints, corev1.Taint{Key: k[:i], Value: change.set[k], Effect: corev1.TaintEffect(k[i+1:])})
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{"resourceVersion": node.ResourceVersion},
+			"spec":     map[string]interface{}{"taints": taints},
+		})
+		if err != nil {
+			return err
+		}
+		_, err = t.GetClientSet().CoreV1().Nodes().Patch(o.name, k8stypes.MergePatchType, patch)
+		return err
+	}
+
+	entries := map[string]interface{}{}
+	for k, v := range change.set {
+		entries[k] = v
+	}
+	for _, k := range change.remove {
+		entries[k] = nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{string(change.field): entries},
+	})
+	if err != nil {
+		return err
+	}
+	r, err := lookupResource(t.GetClientSet(), t.GetResourceKind())
+	if err != nil {
+		return err
+	}
+	client, err := resourceClient(r, o.namespace)
+	if err != nil {
+		return err
+	}
+	_, err = client.Patch(o.name, k8stypes.MergePatchType, patch, metav1.UpdateOptions{})
+	return err
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package k8s
+
+import "testing"
+
+func TestParseEntry(t *testing.T) {
+	tests := []struct {
+		field   metadataField
+		text    string
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{field: labelsField, text: "app=web", key: "app", value: "web"},
+		{field: labelsField, text: "app", key: "app"},
+		{field: annotationsField, text: "a=b=c", key: "a", value: "b=c"},
+		{field: annotationsField, text: "url=http://x:80", key: "url", value: "http://x:80"},
+		{field: taintsField, text: "gpu=true:NoSchedule", key: "gpu:NoSchedule", value: "true"},
+		{field: taintsField, text: "gpu:NoExecute", key: "gpu:NoExecute"},
+		{field: taintsField, text: "gpu=true", wantErr: true},
+		{field: taintsField, text: "gpu=true:Never", wantErr: true},
+	}
+	for _, tt := range tests {
+		key, value, err := parseEntry(tt.field, tt.text)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseEntry(%s, %q) error = %v, wantErr %v", tt.field, tt.text, err, tt.wantErr)
+			continue
+		}
+		if key != tt.key || value != tt.value {
+			t.Errorf("parseEntry(%s, %q) = %q, %q, want %q, %q", tt.field, tt.text, key, value, tt.key, tt.value)
+		}
+	}
+}
+
+func TestFormatEntry(t *testing.T) {
+	tests := []struct {
+		field metadataField
+		key   string
+		value string
+		want  string
+	}{
+		{field: labelsField, key: "app", value: "web", want: "app=web"},
+		{field: annotationsField, key: "a", want: "a="},
+		{field: taintsField, key: "gpu:NoSchedule", value: "true", want: "gpu=true:NoSchedule"},
+		{field: taintsField, key: "gpu:NoExecute", want: "gpu:NoExecute"},
+	}
+	for _, tt := range tests {
+		got := formatEntry(tt.field, tt.key, tt.value)
+		if got != tt.want {
+			t.Errorf("formatEntry(%s, %q, %q) = %q, want %q", tt.field, tt.key, tt.value, got, tt.want)
+		}
+		// what is shown parses back to the same entry
+		key, value, err := parseEntry(tt.field, got)
+		if err != nil || key != tt.key || value != tt.value {
+			t.Errorf("parseEntry(%s, %q) = %q, %q, %v, want %q, %q", tt.field, got, key, value, err, tt.key, tt.value)
+		}
+	}
+}
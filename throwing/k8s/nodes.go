@@ -0,0 +1,360 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/rancher/axe/throwing"
+	"github.com/rancher/axe/throwing/datafeeder"
+	"github.com/rancher/axe/throwing/types"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	drainKind = "drain"
+
+	mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+	// drainRetryInterval is the wait before evicting a pod again when a disruption budget does not allow it yet
+	drainRetryInterval = 5 * time.Second
+	// drainPollInterval is how often evicted pods are checked for being gone, and the page redrawn
+	drainPollInterval = time.Second
+
+	drainEvict = "evict"
+	drainSkip  = "skip"
+
+	drainPending  = "Pending"
+	drainEvicting = "Evicting"
+	drainBlocked  = "Blocked"
+	drainEvicted  = "Evicted"
+	drainFailed   = "Failed"
+	drainCanceled = "Canceled"
+)
+
+var (
+	drainActions = []types.Action{
+		{
+			Name:        "start",
+			Shortcut:    "y",
+			Description: "cordon the node and evict the pods",
+		},
+		{
+			Name:        "cancel",
+			Shortcut:    "c",
+			Description: "stop evicting",
+		},
+	}
+)
+
+func cordon(t *throwing.TableView, unschedulable bool) {
+	if t.GetResourceKind() != "nodes" {
+		return
+	}
+	_, name := getNamespaceAndName(t)
+	action := "cordon"
+	if !unschedulable {
+		action = "uncordon"
+	}
+
+	confirm(t, action, fmt.Sprintf("Do you want to %s node %s?", action, name), func() {
+		if err := setUnschedulable(t.GetClientSet(), name, unschedulable); err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		t.SwitchToRootPage()
+		t.RefreshManual()
+	})
+}
+
+func setUnschedulable(clientset *kubernetes.Clientset, node string, unschedulable bool) error {
+	patch := fmt.Sprintf(`{"spec":{"unschedulable":%t}}`, unschedulable)
+	_, err := clientset.CoreV1().Nodes().Patch(node, k8stypes.MergePatchType, []byte(patch))
+	return err
+}
+
+// drainPod is a pod of the node being drained, with what the drain does with it.
+type drainPod struct {
+	namespace string
+	name      string
+	uid       k8stypes.UID
+	action    string
+	status    string
+	note      string
+}
+
+/*
+nodeDrain is the DataSource of the drain page of a node. It is a preview of what the drain does until started, then
+shows the progress of every pod. Pods are evicted through the Eviction API, so that disruption budgets are honored.
+*/
+type nodeDrain struct {
+	clientset *kubernetes.Clientset
+	node      string
+
+	lock    sync.Mutex
+	pods    []*drainPod
+	started bool
+	cancel  chan struct{}
+}
+
+func (d *nodeDrain) Header() datafeeder.Row {
+	return datafeeder.Row{"NAMESPACE", "NAME", "ACTION", "STATUS", "NOTE"}
+}
+
+func (d *nodeDrain) Data() []datafeeder.Row {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	var rows []datafeeder.Row
+	for _, p := range d.pods {
+		rows = append(rows, datafeeder.Row{p.namespace, p.name, p.action, p.status, p.note})
+	}
+	return rows
+}
+
+// Refresh computes the preview again, once started it only shows the progress.
+func (d *nodeDrain) Refresh() error {
+	d.lock.Lock()
+	started := d.started
+	d.lock.Unlock()
+	if started {
+		return nil
+	}
+
+	pods, err := drainPreview(d.clientset, d.node)
+	if err != nil {
+		return err
+	}
+	d.lock.Lock()
+	d.pods = pods
+	d.lock.Unlock()
+	return nil
+}
+
+// drainPreview lists the pods of the node and what draining does with them, including the budgets that block it.
+func drainPreview(clientset *kubernetes.Clientset, node string) ([]*drainPod, error) {
+	list, err := clientset.CoreV1().Pods("").List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", node).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	budgets, err := clientset.PolicyV1beta1().PodDisruptionBudgets("").List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var pods []*drainPod
+	for _, pod := range list.Items {
+		p := &drainPod{
+			namespace: pod.Namespace,
+			name:      pod.Name,
+			uid:       pod.UID,
+			action:    drainEvict,
+			status:    drainPending,
+		}
+		pods = append(pods, p)
+
+		if _, ok := pod.Annotations[mirrorPodAnnotation]; ok {
+			p.action, p.status, p.note = drainSkip, "-", "mirror pod"
+			continue
+		}
+		controller := metav1.GetControllerOf(&pod)
+		if controller != nil && controller.Kind == "DaemonSet" {
+			p.action, p.status, p.note = drainSkip, "-", "DaemonSet pod"
+			continue
+		}
+
+		var notes []string
+		if controller == nil && pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			notes = append(notes, "not managed by a controller, will not be recreated")
+		}
+		for _, v := range pod.Spec.Volumes {
+			if v.EmptyDir != nil {
+				notes = append(notes, fmt.Sprintf("emptyDir %s will be lost", v.Name))
+			}
+		}
+		for _, budget := range blockingBudgets(budgets.Items, pod) {
+			notes = append(notes, fmt.Sprintf("blocked by PodDisruptionBudget %s", budget))
+		}
+		p.note = strings.Join(notes, ", ")
+	}
+	return pods, nil
+}
+
+// blockingBudgets returns the disruption budgets that select the pod and do not allow any disruption right now.
+func blockingBudgets(budgets []policyv1beta1.PodDisruptionBudget, pod corev1.Pod) []string {
+	var names []string
+	for _, b := range budgets {
+		if b.Namespace != pod.Namespace || b.Status.PodDisruptionsAllowed > 0 || b.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(b.Spec.Selector)
+		if err != nil || selector.Empty() || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		names = append(names, b.Name)
+	}
+	return names
+}
+
+// start cordons the node and evicts its pods in the background. Evictions refused by a budget are tried again until canceled.
+func (d *nodeDrain) start() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.started {
+		return nil
+	}
+	if err := setUnschedulable(d.clientset, d.node, true); err != nil {
+		return err
+	}
+	d.started = true
+	d.cancel = make(chan struct{})
+
+	for _, p := range d.pods {
+		if p.action == drainEvict {
+			go d.evict(p)
+		}
+	}
+	return nil
+}
+
+func (d *nodeDrain) stop() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.cancel == nil {
+		return
+	}
+	close(d.cancel)
+	d.cancel = nil
+	for _, p := range d.pods {
+		if p.action == drainEvict && p.status != drainEvicted && p.status != drainFailed {
+			p.status = drainCanceled
+		}
+	}
+}
+
+func (d *nodeDrain) evict(p *drainPod) {
+	d.lock.Lock()
+	cancel := d.cancel
+	d.lock.Unlock()
+
+	eviction := &policyv1beta1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: p.namespace,
+			Name:      p.name,
+		},
+	}
+	for {
+		d.setStatus(p, drainEvicting, "")
+		err := d.clientset.CoreV1().Pods(p.namespace).Evict(eviction)
+		switch {
+		case err == nil, errors.IsNotFound(err):
+			d.waitGone(p, cancel)
+			return
+		case errors.IsTooManyRequests(err):
+			d.setStatus(p, drainBlocked, "disruption budget does not allow it yet, retrying")
+		default:
+			d.setStatus(p, drainFailed, err.Error())
+			return
+		}
+
+		select {
+		case <-cancel:
+			return
+		case <-time.After(drainRetryInterval):
+		}
+	}
+}
+
+// waitGone waits for an evicted pod to be deleted, a pod with the same name but another UID is a replacement.
+func (d *nodeDrain) waitGone(p *drainPod, cancel chan struct{}) {
+	for {
+		pod, err := d.clientset.CoreV1().Pods(p.namespace).Get(p.name, metav1.GetOptions{})
+		if errors.IsNotFound(err) || (err == nil && pod.UID != p.uid) {
+			d.setStatus(p, drainEvicted, "")
+			return
+		}
+
+		select {
+		case <-cancel:
+			return
+		case <-time.After(drainPollInterval):
+		}
+	}
+}
+
+func (d *nodeDrain) setStatus(p *drainPod, status, note string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if p.status == drainCanceled {
+		return
+	}
+	p.status = status
+	if note != "" {
+		p.note = note
+	}
+}
+
+func drain(t *throwing.TableView) {
+	if t.GetResourceKind() != "nodes" {
+		return
+	}
+	_, name := getNamespaceAndName(t)
+
+	d := &nodeDrain{
+		clientset: t.GetClientSet(),
+		node:      name,
+	}
+	kind := types.ResourceKind{
+		Title: fmt.Sprintf("drain - %s (preview) [y] start", name),
+		Kind:  drainKind,
+	}
+	page := fmt.Sprintf("%s@%s", drainKind, name)
+	newtable := t.NewNestTableView(kind, d, drainActions, nil, drainEventHandler(d))
+	t.SetTableView(page, newtable)
+	t.SwitchPage(page, newtable)
+}
+
+func drainEventHandler(d *nodeDrain) throwing.EventHandler {
+	return func(t *throwing.TableView) func(event *tcell.EventKey) *tcell.EventKey {
+		return func(event *tcell.EventKey) *tcell.EventKey {
+			switch event.Key() {
+			case tcell.KeyEscape:
+				t.BackPage()
+			case tcell.KeyRune:
+				switch event.Rune() {
+				case 'y':
+					confirm(t, "drain", fmt.Sprintf("Do you want to cordon node %s and evict its pods?", d.node), func() {
+						if err := d.start(); err != nil {
+							t.UpdateStatus(err.Error(), true)
+							return
+						}
+						t.GetTable().SetTitle(fmt.Sprintf("drain - %s [c] cancel", d.node))
+						t.SwitchToRootPage()
+						t.RefreshManual()
+						// show the progress while the page is shown, refreshing only reads it from memory once started
+						t.RefreshEvery(drainPollInterval, nil)
+					})
+				case 'c':
+					d.stop()
+					t.GetTable().SetTitle(fmt.Sprintf("drain - %s (canceled)", d.node))
+					t.RefreshManual()
+				case 'r':
+					t.RefreshManual()
+				case 'q':
+					t.RootPage()
+				}
+			}
+			return event
+		}
+	}
+}
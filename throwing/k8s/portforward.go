@@ -0,0 +1,513 @@
+package k8s
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/rancher/axe/throwing"
+	"github.com/rancher/axe/throwing/datafeeder"
+	"github.com/rancher/axe/throwing/types"
+	"github.com/rivo/tview"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+const (
+	portForwardKind = "portforwards"
+
+	forwardStarting = "Starting"
+	forwardActive   = "Active"
+	forwardStopped  = "Stopped"
+	forwardFailed   = "Failed"
+)
+
+var (
+	forwards = &portForwardManager{}
+
+	portForwardResourceKind = types.ResourceKind{
+		Title: "Port Forwards",
+		Kind:  portForwardKind,
+	}
+
+	portForwardActions = []types.Action{
+		{
+			Name:        "stop",
+			Shortcut:    "s",
+			Description: "stop a port forward",
+		},
+		{
+			Name:        "restart",
+			Shortcut:    "R",
+			Description: "restart a port forward",
+		},
+	}
+
+	portForwardEventHandler = func(t *throwing.TableView) func(event *tcell.EventKey) *tcell.EventKey {
+		return func(event *tcell.EventKey) *tcell.EventKey {
+			switch event.Rune() {
+			case 's':
+				if pf := forwards.get(t.GetSelectionName()); pf != nil {
+					pf.Stop()
+				}
+				t.RefreshManual()
+			case 'R':
+				if pf := forwards.get(t.GetSelectionName()); pf != nil {
+					pf.Restart(t.GetClientSet())
+				}
+				t.RefreshManual()
+			case 'r':
+				t.RefreshManual()
+			case 'q':
+				t.RootPage()
+			}
+			if event.Key() == tcell.KeyEscape {
+				t.BackPage()
+			}
+			return event
+		}
+	}
+)
+
+// portForward forwards a local port to a port of a pod. Services are resolved to one of their pods when the forward is created.
+type portForward struct {
+	// accessed atomically, kept first for 64-bit alignment
+	sent     int64
+	received int64
+
+	id         string
+	namespace  string
+	pod        string
+	target     string
+	localPort  int
+	remotePort int
+
+	lock     sync.Mutex
+	status   string
+	stopChan chan struct{}
+	// done is closed once the forwarder of the last start returned and released the local port
+	done       chan struct{}
+	restarting bool
+}
+
+func (pf *portForward) Start(clientset *kubernetes.Clientset) {
+	pf.lock.Lock()
+	defer pf.lock.Unlock()
+
+	pf.status = forwardStarting
+	pf.stopChan = make(chan struct{})
+	stopChan := pf.stopChan
+	readyChan := make(chan struct{})
+	done := make(chan struct{})
+	pf.done = done
+
+	go func() {
+		defer close(done)
+		errB := &strings.Builder{}
+		err := pf.forward(clientset, stopChan, readyChan, errB)
+
+		pf.lock.Lock()
+		defer pf.lock.Unlock()
+		if pf.stopChan != stopChan {
+			// restarted in the meantime, the status belongs to the new forwarder
+			return
+		}
+		select {
+		case <-stopChan:
+			pf.status = forwardStopped
+		default:
+			if err == nil && errB.Len() > 0 {
+				err = fmt.Errorf("%s", strings.TrimSpace(errB.String()))
+			}
+			if err != nil {
+				pf.status = fmt.Sprintf("%s: %v", forwardFailed, err)
+			} else {
+				pf.status = forwardStopped
+			}
+		}
+	}()
+
+	go func() {
+		select {
+		case <-readyChan:
+			pf.lock.Lock()
+			if pf.stopChan == stopChan && pf.status == forwardStarting {
+				pf.status = forwardActive
+			}
+			pf.lock.Unlock()
+		case <-stopChan:
+		}
+	}()
+}
+
+func (pf *portForward) forward(clientset *kubernetes.Clientset, stopChan, readyChan chan struct{}, errB *strings.Builder) error {
+	restConfig, err := getRestConfig()
+	if err != nil {
+		return err
+	}
+	transport, upgrader, err := spdy.RoundTripperFor(restConfig)
+	if err != nil {
+		return err
+	}
+
+	url := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pf.namespace).
+		Name(pf.pod).
+		SubResource("portforward").
+		URL()
+	dialer := countingDialer{
+		Dialer: spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", url),
+		pf:     pf,
+	}
+
+	ports := []string{fmt.Sprintf("%d:%d", pf.localPort, pf.remotePort)}
+	fw, err := portforward.New(dialer, ports, stopChan, readyChan, ioutil.Discard, errB)
+	if err != nil {
+		return err
+	}
+	return fw.ForwardPorts()
+}
+
+func (pf *portForward) Stop() {
+	pf.lock.Lock()
+	defer pf.lock.Unlock()
+
+	select {
+	case <-pf.stopChan:
+	default:
+		close(pf.stopChan)
+	}
+	pf.status = forwardStopped
+}
+
+/*
+Restart stops the forward and starts it again once the forwarder returned, binding the local port while the previous
+listener still holds it would fail.
+*/
+func (pf *portForward) Restart(clientset *kubernetes.Clientset) {
+	pf.Stop()
+
+	pf.lock.Lock()
+	defer pf.lock.Unlock()
+	if pf.restarting {
+		return
+	}
+	pf.restarting = true
+	pf.status = forwardStarting
+	done := pf.done
+	go func() {
+		if done != nil {
+			<-done
+		}
+		pf.lock.Lock()
+		pf.restarting = false
+		pf.lock.Unlock()
+		pf.Start(clientset)
+	}()
+}
+
+func (pf *portForward) Status() string {
+	pf.lock.Lock()
+	defer pf.lock.Unlock()
+	return pf.status
+}
+
+type portForwardManager struct {
+	lock     sync.Mutex
+	forwards []*portForward
+	nextID   int
+}
+
+func (m *portForwardManager) add(pf *portForward) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.nextID++
+	pf.id = strconv.Itoa(m.nextID)
+	m.forwards = append(m.forwards, pf)
+}
+
+func (m *portForwardManager) get(id string) *portForward {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, pf := range m.forwards {
+		if pf.id == id {
+			return pf
+		}
+	}
+	return nil
+}
+
+func (m *portForwardManager) list() []*portForward {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([]*portForward(nil), m.forwards...)
+}
+
+// StopAll tears down every forward, it is called when axe exits.
+func (m *portForwardManager) StopAll() {
+	for _, pf := range m.list() {
+		pf.Stop()
+	}
+}
+
+func (m *portForwardManager) refresh(b *bytes.Buffer) error {
+	header := []string{"ID", "LOCAL", "TARGET", "POD", "REMOTE", "SENT", "RECEIVED", "STATUS"}
+	b.WriteString(strings.Join(header, "\t"))
+	b.WriteString("\n")
+	for _, pf := range m.list() {
+		row := []string{
+			pf.id,
+			fmt.Sprintf("localhost:%d", pf.localPort),
+			pf.target,
+			fmt.Sprintf("%s/%s", pf.namespace, pf.pod),
+			strconv.Itoa(pf.remotePort),
+			formatBytes(atomic.LoadInt64(&pf.sent)),
+			formatBytes(atomic.LoadInt64(&pf.received)),
+			pf.Status(),
+		}
+		b.WriteString(strings.Join(row, "\t"))
+		b.WriteString("\n")
+	}
+	return nil
+}
+
+type countingDialer struct {
+	httpstream.Dialer
+	pf *portForward
+}
+
+func (d countingDialer) Dial(protocols ...string) (httpstream.Connection, string, error) {
+	conn, protocol, err := d.Dialer.Dial(protocols...)
+	if err != nil {
+		return nil, "", err
+	}
+	return countingConnection{Connection: conn, pf: d.pf}, protocol, nil
+}
+
+type countingConnection struct {
+	httpstream.Connection
+	pf *portForward
+}
+
+func (c countingConnection) CreateStream(headers http.Header) (httpstream.Stream, error) {
+	stream, err := c.Connection.CreateStream(headers)
+	if err != nil {
+		return nil, err
+	}
+	return countingStream{Stream: stream, pf: c.pf}, nil
+}
+
+type countingStream struct {
+	httpstream.Stream
+	pf *portForward
+}
+
+func (s countingStream) Read(p []byte) (int, error) {
+	n, err := s.Stream.Read(p)
+	atomic.AddInt64(&s.pf.received, int64(n))
+	return n, err
+}
+
+func (s countingStream) Write(p []byte) (int, error) {
+	n, err := s.Stream.Write(p)
+	atomic.AddInt64(&s.pf.sent, int64(n))
+	return n, err
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+type forwardPort struct {
+	name string
+	port int
+	// target is the pod port a service port points at, either a number or a named container port
+	target intstr.IntOrString
+}
+
+func portForwardDialog(t *throwing.TableView) {
+	kind := t.GetResourceKind()
+	if kind != "pods" && kind != "services" {
+		return
+	}
+
+	namespace, name := getNamespaceAndName(t)
+	clientset := t.GetClientSet()
+
+	var ports []forwardPort
+	switch kind {
+	case "pods":
+		pod, err := clientset.CoreV1().Pods(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		for _, c := range pod.Spec.Containers {
+			for _, p := range c.Ports {
+				ports = append(ports, forwardPort{name: p.Name, port: int(p.ContainerPort), target: intstr.FromInt(int(p.ContainerPort))})
+			}
+		}
+	case "services":
+		svc, err := clientset.CoreV1().Services(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		if len(svc.Spec.Selector) == 0 {
+			t.UpdateStatus(fmt.Sprintf("service %s has no selector", name), true)
+			return
+		}
+		for _, p := range svc.Spec.Ports {
+			target := p.TargetPort
+			if target.Type == intstr.Int && target.IntValue() == 0 {
+				target = intstr.FromInt(int(p.Port))
+			}
+			ports = append(ports, forwardPort{name: p.Name, port: int(p.Port), target: target})
+		}
+	}
+
+	var options []string
+	for _, p := range ports {
+		options = append(options, strings.Trim(fmt.Sprintf("%d %s", p.port, p.name), " "))
+	}
+
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle(fmt.Sprintf("port-forward - (%s)", name))
+	local := tview.NewInputField().SetLabel("Local port").SetFieldWidth(10).SetAcceptanceFunc(tview.InputFieldInteger)
+	remote := tview.NewInputField().SetLabel("Remote port").SetFieldWidth(10).SetAcceptanceFunc(tview.InputFieldInteger)
+	selected := forwardPort{}
+	if len(ports) > 0 {
+		selected = ports[0]
+		local.SetText(strconv.Itoa(selected.port))
+		remote.SetText(strconv.Itoa(selected.port))
+		form.AddDropDown("Port", options, 0, func(option string, optionIndex int) {
+			selected = ports[optionIndex]
+			local.SetText(strconv.Itoa(selected.port))
+			remote.SetText(strconv.Itoa(selected.port))
+		})
+	}
+	form.AddFormItem(local)
+	form.AddFormItem(remote)
+	form.AddButton("forward", func() {
+		localPort, _ := strconv.Atoi(local.GetText())
+		remotePort, _ := strconv.Atoi(remote.GetText())
+		if remotePort != selected.port {
+			selected = forwardPort{port: remotePort, target: intstr.FromInt(remotePort)}
+		}
+
+		pf, err := newPortForward(clientset, kind, namespace, name, localPort, selected)
+		if err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		forwards.add(pf)
+		pf.Start(clientset)
+		t.UpdateStatus(fmt.Sprintf("forwarding localhost:%d to %s", pf.localPort, pf.target), false)
+	})
+	form.AddButton("Cancel", func() {
+		t.BackPage()
+	})
+	form.SetCancelFunc(func() {
+		t.BackPage()
+	})
+	t.InsertDialog("port-forward", t.GetCurrentPrimitive(), form)
+}
+
+// newPortForward resolves the pod and pod port to forward to. For services a running pod matching the selector is picked and named target ports are looked up in its containers.
+func newPortForward(clientset *kubernetes.Clientset, kind, namespace, name string, localPort int, port forwardPort) (*portForward, error) {
+	if port.port == 0 {
+		return nil, fmt.Errorf("no remote port given")
+	}
+	if localPort == 0 {
+		localPort = port.port
+	}
+
+	pf := &portForward{
+		namespace: namespace,
+		localPort: localPort,
+		status:    forwardStarting,
+		stopChan:  make(chan struct{}),
+	}
+	if kind == "pods" {
+		pf.pod = name
+		pf.target = fmt.Sprintf("pod/%s:%d", name, port.port)
+		pf.remotePort = port.target.IntValue()
+		return pf, nil
+	}
+
+	svc, err := clientset.CoreV1().Services(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(svc.Spec.Selector).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var pod *corev1.Pod
+	for i, p := range pods.Items {
+		if p.Status.Phase == corev1.PodRunning && p.DeletionTimestamp == nil {
+			pod = &pods.Items[i]
+			break
+		}
+	}
+	if pod == nil {
+		return nil, fmt.Errorf("no running pod found for service %s", name)
+	}
+
+	pf.pod = pod.Name
+	pf.target = fmt.Sprintf("svc/%s:%d", name, port.port)
+	pf.remotePort = port.target.IntValue()
+	if port.target.Type == intstr.String {
+		for _, c := range pod.Spec.Containers {
+			for _, p := range c.Ports {
+				if p.Name == port.target.StrVal {
+					pf.remotePort = int(p.ContainerPort)
+				}
+			}
+		}
+		if pf.remotePort == 0 {
+			return nil, fmt.Errorf("pod %s has no container port named %s", pod.Name, port.target.StrVal)
+		}
+	}
+	return pf, nil
+}
+
+func viewPortForwards(t *throwing.TableView) {
+	newtable := t.GetNestedTable(portForwardKind)
+	if newtable == nil {
+		newtable = t.NewNestTableView(portForwardResourceKind, datafeeder.NewDataFeeder(forwards.refresh), portForwardActions, nil, portForwardEventHandler)
+		t.SetTableView(portForwardKind, newtable)
+		// keep the byte counters and status live while the page is shown
+		newtable.RefreshEvery(time.Second, nil)
+	}
+	t.SwitchPage(portForwardKind, newtable)
+	newtable.RefreshManual()
+}
@@ -0,0 +1,360 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/rancher/axe/throwing"
+	"github.com/rancher/axe/throwing/datafeeder"
+	"github.com/rancher/axe/throwing/types"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	problemsKind = "problems"
+
+	// problemsInterval is how often the rules are evaluated again while the page is shown
+	problemsInterval = 10 * time.Second
+
+	SeverityCritical = "Critical"
+	SeverityWarning  = "Warning"
+)
+
+var (
+	problemsResourceKind = types.ResourceKind{
+		Title: "Problems",
+		Kind:  problemsKind,
+	}
+
+	problemRules = []namedRule{
+		{"pod-not-ready", podProblems},
+		{"pod-unschedulable", unschedulablePods},
+		{"node-not-ready", nodeProblems},
+		{"deployment-unavailable", deploymentProblems},
+		{"job-failed", jobProblems},
+		{"pvc-pending", claimProblems},
+	}
+	problemRulesLock sync.Mutex
+)
+
+// Problem is a finding of a rule about one object.
+type Problem struct {
+	Severity string
+	// Kind is the resource of the object as shown in tables, e.g. pods or deployments.apps
+	Kind      string
+	Namespace string
+	Name      string
+	Message   string
+}
+
+/*
+ClusterState is what the rules are evaluated against. It is listed once for every evaluation, rules that need other
+objects can read them with the Clientset.
+*/
+type ClusterState struct {
+	Clientset   *kubernetes.Clientset
+	Pods        []corev1.Pod
+	Nodes       []corev1.Node
+	Deployments []appsv1.Deployment
+	Jobs        []batchv1.Job
+	Claims      []corev1.PersistentVolumeClaim
+	// SchedulingEvents are the FailedScheduling events, keyed by the UID of their pod
+	SchedulingEvents map[string][]corev1.Event
+}
+
+// ProblemRule evaluates the cluster and returns the problems it finds.
+type ProblemRule func(state *ClusterState) []Problem
+
+type namedRule struct {
+	name string
+	rule ProblemRule
+}
+
+// RegisterProblemRule adds a rule to the problems page. The name is shown next to the problems the rule finds.
+func RegisterProblemRule(name string, rule ProblemRule) {
+	problemRulesLock.Lock()
+	defer problemRulesLock.Unlock()
+	problemRules = append(problemRules, namedRule{name, rule})
+}
+
+// problemsReport is the DataSource of the problems page, rows are the problems found by every rule.
+type problemsReport struct {
+	clientset *kubernetes.Clientset
+	rows      []datafeeder.Row
+}
+
+func (p *problemsReport) Header() datafeeder.Row {
+	return datafeeder.Row{"SEVERITY", "NAMESPACE", "NAME", "RESOURCE", "RULE", "MESSAGE"}
+}
+
+func (p *problemsReport) Data() []datafeeder.Row {
+	return p.rows
+}
+
+func (p *problemsReport) Refresh() error {
+	state, err := newClusterState(p.clientset)
+	if err != nil {
+		return err
+	}
+
+	problemRulesLock.Lock()
+	rules := append([]namedRule{}, problemRules...)
+	problemRulesLock.Unlock()
+
+	var rows []datafeeder.Row
+	for _, r := range rules {
+		for _, problem := range r.rule(state) {
+			// cells are single lines
+			message := strings.Join(strings.Fields(problem.Message), " ")
+			rows = append(rows, datafeeder.Row{problem.Severity, problem.Namespace, problem.Name, problem.Kind, r.name, message})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i][0] != rows[j][0] {
+			return rows[i][0] == SeverityCritical
+		}
+		return rows[i][1] < rows[j][1]
+	})
+	p.rows = rows
+	return nil
+}
+
+func newClusterState(clientset *kubernetes.Clientset) (*ClusterState, error) {
+	state := &ClusterState{
+		Clientset:        clientset,
+		SchedulingEvents: map[string][]corev1.Event{},
+	}
+	listOptions := metav1.ListOptions{}
+
+	pods, err := clientset.CoreV1().Pods("").List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	state.Pods = pods.Items
+
+	nodes, err := clientset.CoreV1().Nodes().List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	state.Nodes = nodes.Items
+
+	deployments, err := clientset.AppsV1().Deployments("").List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	state.Deployments = deployments.Items
+
+	jobs, err := clientset.BatchV1().Jobs("").List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	state.Jobs = jobs.Items
+
+	claims, err := clientset.CoreV1().PersistentVolumeClaims("").List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	state.Claims = claims.Items
+
+	events, err := clientset.CoreV1().Events("").List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("reason", "FailedScheduling").String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events.Items {
+		uid := string(e.InvolvedObject.UID)
+		state.SchedulingEvents[uid] = append(state.SchedulingEvents[uid], e)
+	}
+	return state, nil
+}
+
+func podProblems(state *ClusterState) []Problem {
+	var problems []Problem
+	for _, pod := range state.Pods {
+		if pod.Status.Phase == corev1.PodSucceeded {
+			continue
+		}
+		problem := Problem{Kind: "pods", Namespace: pod.Namespace, Name: pod.Name}
+		var waiting []string
+		for _, s := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			w := s.State.Waiting
+			if w == nil {
+				continue
+			}
+			switch w.Reason {
+			case "CrashLoopBackOff":
+				waiting = append(waiting, fmt.Sprintf("%s is crash-looping (%d restarts)", s.Name, s.RestartCount))
+			case "ImagePullBackOff", "ErrImagePull", "InvalidImageName", "CreateContainerConfigError":
+				waiting = append(waiting, fmt.Sprintf("%s: %s %s", s.Name, w.Reason, w.Message))
+			}
+		}
+		if len(waiting) > 0 {
+			problem.Severity = SeverityCritical
+			problem.Message = strings.Join(waiting, ", ")
+			problems = append(problems, problem)
+			continue
+		}
+		if pod.Status.Phase == corev1.PodFailed {
+			problem.Severity = SeverityCritical
+			problem.Message = fmt.Sprintf("failed: %s %s", pod.Status.Reason, pod.Status.Message)
+			problems = append(problems, problem)
+			continue
+		}
+		for _, c := range pod.Status.Conditions {
+			if c.Type == corev1.PodReady && c.Status != corev1.ConditionTrue && pod.Status.Phase == corev1.PodRunning {
+				problem.Severity = SeverityWarning
+				problem.Message = fmt.Sprintf("not ready for %s %s", since(c.LastTransitionTime), c.Message)
+				problems = append(problems, problem)
+			}
+		}
+	}
+	return problems
+}
+
+func unschedulablePods(state *ClusterState) []Problem {
+	var problems []Problem
+	for _, pod := range state.Pods {
+		events := state.SchedulingEvents[string(pod.UID)]
+		if pod.Status.Phase != corev1.PodPending || len(events) == 0 {
+			continue
+		}
+		sort.Slice(events, func(i, j int) bool {
+			return eventTime(events[i]).Time.Before(eventTime(events[j]).Time)
+		})
+		problems = append(problems, Problem{
+			Severity:  SeverityWarning,
+			Kind:      "pods",
+			Namespace: pod.Namespace,
+			Name:      pod.Name,
+			Message:   "pending: " + events[len(events)-1].Message,
+		})
+	}
+	return problems
+}
+
+func nodeProblems(state *ClusterState) []Problem {
+	var problems []Problem
+	for _, node := range state.Nodes {
+		for _, c := range node.Status.Conditions {
+			problem := Problem{Kind: "nodes", Name: node.Name}
+			switch {
+			case c.Type == corev1.NodeReady && c.Status != corev1.ConditionTrue:
+				problem.Severity = SeverityCritical
+				problem.Message = fmt.Sprintf("NotReady for %s: %s", since(c.LastTransitionTime), c.Message)
+			case c.Type != corev1.NodeReady && c.Status == corev1.ConditionTrue:
+				problem.Severity = SeverityWarning
+				problem.Message = fmt.Sprintf("%s: %s", c.Type, c.Message)
+			default:
+				continue
+			}
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+func deploymentProblems(state *ClusterState) []Problem {
+	var problems []Problem
+	for _, d := range state.Deployments {
+		if d.Status.UnavailableReplicas == 0 {
+			continue
+		}
+		severity := SeverityWarning
+		if d.Status.AvailableReplicas == 0 {
+			severity = SeverityCritical
+		}
+		problems = append(problems, Problem{
+			Severity:  severity,
+			Kind:      "deployments.apps",
+			Namespace: d.Namespace,
+			Name:      d.Name,
+			Message:   fmt.Sprintf("%d of %d replicas unavailable", d.Status.UnavailableReplicas, d.Status.Replicas),
+		})
+	}
+	return problems
+}
+
+func jobProblems(state *ClusterState) []Problem {
+	var problems []Problem
+	for _, j := range state.Jobs {
+		for _, c := range j.Status.Conditions {
+			if c.Type != batchv1.JobFailed || c.Status != corev1.ConditionTrue {
+				continue
+			}
+			problems = append(problems, Problem{
+				Severity:  SeverityCritical,
+				Kind:      "jobs.batch",
+				Namespace: j.Namespace,
+				Name:      j.Name,
+				Message:   fmt.Sprintf("failed %s ago: %s %s", since(c.LastTransitionTime), c.Reason, c.Message),
+			})
+		}
+	}
+	return problems
+}
+
+func claimProblems(state *ClusterState) []Problem {
+	var problems []Problem
+	for _, c := range state.Claims {
+		if c.Status.Phase != corev1.ClaimPending {
+			continue
+		}
+		problems = append(problems, Problem{
+			Severity:  SeverityWarning,
+			Kind:      "persistentvolumeclaims",
+			Namespace: c.Namespace,
+			Name:      c.Name,
+			Message:   fmt.Sprintf("pending for %s", since(c.CreationTimestamp)),
+		})
+	}
+	return problems
+}
+
+func problemsEventHandler(t *throwing.TableView) func(event *tcell.EventKey) *tcell.EventKey {
+	return func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			table := t.GetTable()
+			row, _ := table.GetSelection()
+			if row > 0 {
+				showObject(t, table.GetCell(row, 3).Text, table.GetCell(row, 1).Text, table.GetCell(row, 2).Text)
+			}
+		case tcell.KeyEscape:
+			t.BackPage()
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'r':
+				t.RefreshManual()
+			case 'q':
+				t.RootPage()
+			case '/':
+				t.ShowSearch()
+			}
+		}
+		return event
+	}
+}
+
+func viewProblems(t *throwing.TableView) {
+	newtable := t.GetNestedTable(problemsKind)
+	if newtable == nil {
+		report := &problemsReport{clientset: t.GetClientSet()}
+		newtable = t.NewNestTableView(problemsResourceKind, report, nil, nil, problemsEventHandler)
+		t.SetTableView(problemsKind, newtable)
+		// keep evaluating the rules while the page is shown
+		newtable.RefreshEvery(problemsInterval, nil)
+	} else {
+		newtable.RefreshManual()
+	}
+	t.SwitchPage(problemsKind, newtable)
+}
@@ -12,12 +12,16 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/apis/meta/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
 type wrapper struct {
 	group, version, name string
+	// namespace and ownerUID narrow the table down to the objects owned by one owner, used by drill-down
+	namespace string
+	ownerUID  k8stypes.UID
 }
 
 func (w wrapper) refreshResource(b *bytes.Buffer) error {
@@ -38,7 +42,7 @@ func (w wrapper) refreshResource(b *bytes.Buffer) error {
 	if w.version == "" {
 		w.version = "v1"
 	}
-	req := restClient.Get().Prefix(apiPrefix, w.group, w.version).Resource(w.name).Param("includeObject", "Object")
+	req := restClient.Get().Prefix(apiPrefix, w.group, w.version).Namespace(w.namespace).Resource(w.name).Param("includeObject", "Object")
 	header := "application/json;as=Table;g=meta.k8s.io;v=v1beta1, application/json"
 	req.SetHeader("Accept", header)
 	table := &v1beta1.Table{}
@@ -67,6 +71,24 @@ func (w wrapper) refreshResource(b *bytes.Buffer) error {
 		}, table.ColumnDefinitions...)
 	}
 
+	// count the recent warnings of every object, events are not counted themselves
+	var warnings map[k8stypes.UID]int
+	warningsKnown := false
+	if w.name != "events" {
+		warnings, warningsKnown = namespaceEvents(clientset, w.namespace).recentWarnings()
+		table.ColumnDefinitions = append(table.ColumnDefinitions, v1beta1.TableColumnDefinition{
+			Name: "WARNINGS",
+		})
+	}
+
+	// show when the certificates of secrets expire
+	secrets := w.group == "" && w.name == "secrets"
+	if secrets {
+		table.ColumnDefinitions = append(table.ColumnDefinitions, v1beta1.TableColumnDefinition{
+			Name: "EXPIRES",
+		})
+	}
+
 	for i, header := range table.ColumnDefinitions {
 		b.Write([]byte(strings.ToUpper(header.Name)))
 		if i == len(table.ColumnDefinitions)-1 {
@@ -87,9 +109,30 @@ func (w wrapper) refreshResource(b *bytes.Buffer) error {
 		if ok {
 			namespace = object.GetNamespace()
 		}
+		if w.ownerUID != "" && !(ok && ownedBy(object, w.ownerUID)) {
+			continue
+		}
 		if namespaced {
 			row.Cells = append([]interface{}{namespace}, row.Cells...)
 		}
+		if w.name != "events" {
+			// the warnings are unknown if the events could not be listed, e.g. when forbidden
+			var count interface{} = "-"
+			if warningsKnown {
+				count = 0
+				if ok {
+					count = warnings[object.GetUID()]
+				}
+			}
+			row.Cells = append(row.Cells, count)
+		}
+		if secrets {
+			expires := "-"
+			if u, ok := converted.(*unstructured.Unstructured); ok {
+				expires = secretExpiry(u)
+			}
+			row.Cells = append(row.Cells, expires)
+		}
 		for i, column := range row.Cells {
 			b.Write([]byte(convert.ToString(column)))
 			if i == len(row.Cells)-1 {
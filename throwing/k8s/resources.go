@@ -0,0 +1,156 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rancher/norman/pkg/kv"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// apiResource is a resource resolved through discovery, so that any kind, including CRDs, can be read with the dynamic client.
+type apiResource struct {
+	gvr        schema.GroupVersionResource
+	kind       string
+	namespaced bool
+	verbs      []string
+}
+
+var (
+	resourceCache     = map[string]apiResource{}
+	resourceCacheLock sync.Mutex
+
+	// dynamicClientCache is built from the kubeconfig on first use, it does not change while axe runs
+	dynamicClientCache dynamic.Interface
+	dynamicClientLock  sync.Mutex
+)
+
+// notServedError tells that the server serves a resource in no version, so that there are no such objects.
+type notServedError string
+
+func (e notServedError) Error() string {
+	return fmt.Sprintf("resource %s not found", string(e))
+}
+
+func (r apiResource) can(verb string) bool {
+	for _, v := range r.verbs {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}
+
+// tableKind returns the kind a TableView shows such a resource under, as built by viewResource.
+func (r apiResource) tableKind() string {
+	if r.gvr.Group == "" {
+		return r.gvr.Resource
+	}
+	return r.gvr.Resource + "." + r.gvr.Group
+}
+
+/*
+lookupResource resolves a table kind such as `pods` or `deployments.apps` to the preferred version of the resource.
+*/
+func lookupResource(clientset *kubernetes.Clientset, resource string) (apiResource, error) {
+	resourceCacheLock.Lock()
+	defer resourceCacheLock.Unlock()
+
+	if r, ok := resourceCache[resource]; ok {
+		return r, nil
+	}
+
+	name, group := kv.Split(resource, ".")
+	lists, err := clientset.Discovery().ServerPreferredResources()
+	if err != nil && len(lists) == 0 {
+		return apiResource{}, err
+	}
+	for _, l := range lists {
+		gv, err := schema.ParseGroupVersion(l.GroupVersion)
+		if err != nil || gv.Group != group {
+			continue
+		}
+		for _, r := range l.APIResources {
+			if r.Name == name {
+				resourceCache[resource] = newAPIResource(gv, r)
+				return resourceCache[resource], nil
+			}
+		}
+	}
+	if err != nil {
+		// a group failed discovery, the resource may be one of its
+		return apiResource{}, err
+	}
+	return apiResource{}, notServedError(resource)
+}
+
+// lookupKind resolves the apiVersion and kind found in owner and object references to a resource.
+func lookupKind(clientset *kubernetes.Clientset, apiVersion, kind string) (apiResource, error) {
+	key := apiVersion + "/" + kind
+	resourceCacheLock.Lock()
+	defer resourceCacheLock.Unlock()
+
+	if r, ok := resourceCache[key]; ok {
+		return r, nil
+	}
+
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return apiResource{}, err
+	}
+	list, err := clientset.Discovery().ServerResourcesForGroupVersion(apiVersion)
+	if err != nil {
+		return apiResource{}, err
+	}
+	for _, r := range list.APIResources {
+		if r.Kind == kind && !strings.Contains(r.Name, "/") {
+			resourceCache[key] = newAPIResource(gv, r)
+			return resourceCache[key], nil
+		}
+	}
+	return apiResource{}, fmt.Errorf("kind %s not found in %s", kind, apiVersion)
+}
+
+func newAPIResource(gv schema.GroupVersion, r metav1.APIResource) apiResource {
+	return apiResource{
+		gvr:        gv.WithResource(r.Name),
+		kind:       r.Kind,
+		namespaced: r.Namespaced,
+		verbs:      r.Verbs,
+	}
+}
+
+func dynamicClient() (dynamic.Interface, error) {
+	dynamicClientLock.Lock()
+	defer dynamicClientLock.Unlock()
+
+	if dynamicClientCache != nil {
+		return dynamicClientCache, nil
+	}
+	restConfig, err := getRestConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClientCache = client
+	return client, nil
+}
+
+// resourceClient returns a dynamic client for the resource, scoped to the namespace if the resource is namespaced.
+func resourceClient(r apiResource, namespace string) (dynamic.ResourceInterface, error) {
+	client, err := dynamicClient()
+	if err != nil {
+		return nil, err
+	}
+	if r.namespaced {
+		return client.Resource(r.gvr).Namespace(namespace), nil
+	}
+	return client.Resource(r.gvr), nil
+}
@@ -0,0 +1,430 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/ghodss/yaml"
+	"github.com/rancher/axe/throwing"
+	"github.com/rancher/axe/throwing/datafeeder"
+	"github.com/rancher/axe/throwing/types"
+	"github.com/rivo/tview"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	historyKind = "history"
+
+	restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+	revisionAnnotation    = "deployment.kubernetes.io/revision"
+	changeCauseAnnotation = "kubernetes.io/change-cause"
+	podTemplateHashLabel  = "pod-template-hash"
+)
+
+var (
+	// rolloutKinds are the kinds that roll their pods out from a template and keep a history of it
+	rolloutKinds = map[string]bool{
+		"Deployment":  true,
+		"StatefulSet": true,
+		"DaemonSet":   true,
+	}
+
+	historyActions = []types.Action{
+		{
+			Name:        "diff",
+			Shortcut:    "Enter",
+			Description: "diff a revision against the current template",
+		},
+		{
+			Name:        "rollback",
+			Shortcut:    "u",
+			Description: "roll back to a revision",
+		},
+	}
+)
+
+// revision is a revision of the template of a workload, with the patch that rolls the workload back to it.
+type revision struct {
+	number      int64
+	changeCause string
+	created     metav1.Time
+	source      string
+	template    map[string]interface{}
+	patchType   k8stypes.PatchType
+	patch       []byte
+}
+
+// hasSubresource tells whether the resource serves a subresource, as advertised by discovery. CRDs can serve scale too.
+func hasSubresource(clientset *kubernetes.Clientset, r apiResource, subresource string) (bool, error) {
+	list, err := clientset.Discovery().ServerResourcesForGroupVersion(r.gvr.GroupVersion().String())
+	if err != nil {
+		return false, err
+	}
+	for _, res := range list.APIResources {
+		if res.Name == r.gvr.Resource+"/"+subresource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func scale(t *throwing.TableView) {
+	objects := selectedObjects(t)
+	r, err := lookupResource(t.GetClientSet(), t.GetResourceKind())
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	ok, err := hasSubresource(t.GetClientSet(), r, "scale")
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	if !ok {
+		t.UpdateStatus(fmt.Sprintf("%s can not be scaled", t.GetResourceKind()), true)
+		return
+	}
+	client, err := resourceClient(r, objects[0].namespace)
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	current, err := client.Get(objects[0].name, metav1.GetOptions{}, "scale")
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	from := nestedInt(current, "spec", "replicas")
+
+	replicas := tview.NewInputField().SetLabel("Replicas").SetFieldWidth(10).SetAcceptanceFunc(tview.InputFieldInteger)
+	replicas.SetText(fmt.Sprint(from))
+	form := tview.NewForm()
+	title := fmt.Sprintf("scale - (%s)", objects[0].name)
+	if len(objects) > 1 {
+		title = fmt.Sprintf("scale - (%d objects)", len(objects))
+	}
+	form.SetBorder(true).SetTitle(title)
+	form.AddFormItem(replicas)
+	form.AddButton("scale", func() {
+		to, err := strconv.ParseInt(replicas.GetText(), 10, 64)
+		if err != nil || to < 0 {
+			t.UpdateStatus(fmt.Sprintf("invalid number of replicas %q", replicas.GetText()), true)
+			return
+		}
+		text := fmt.Sprintf("Do you want to scale %s from %d to %d replicas?", describeObjects(t, objects), from, to)
+		if len(objects) > 1 {
+			text = fmt.Sprintf("Do you want to scale %s to %d replicas?", describeObjects(t, objects), to)
+		}
+		confirm(t, "scale", text, func() {
+			applyToObjects(t, "scale", objects, func(o objectRef) error {
+				return scaleObject(r, o, to)
+			})
+		})
+	})
+	form.AddButton("Cancel", func() {
+		t.BackPage()
+	})
+	form.SetCancelFunc(func() {
+		t.BackPage()
+	})
+	t.InsertDialog("scale", t.GetCurrentPrimitive(), form)
+}
+
+func scaleObject(r apiResource, o objectRef, replicas int64) error {
+	client, err := resourceClient(r, o.namespace)
+	if err != nil {
+		return err
+	}
+	current, err := client.Get(o.name, metav1.GetOptions{}, "scale")
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(current.Object, replicas, "spec", "replicas"); err != nil {
+		return err
+	}
+	_, err = client.Update(current, metav1.UpdateOptions{}, "scale")
+	return err
+}
+
+// restart restarts the pods of workloads by changing an annotation of their template, as kubectl rollout restart does.
+func restart(t *throwing.TableView) {
+	objects := selectedObjects(t)
+	r, err := lookupResource(t.GetClientSet(), t.GetResourceKind())
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	if !rolloutKinds[r.kind] {
+		t.UpdateStatus(fmt.Sprintf("%s can not be restarted", t.GetResourceKind()), true)
+		return
+	}
+
+	confirm(t, "restart", fmt.Sprintf("Do you want to restart the pods of %s?", describeObjects(t, objects)), func() {
+		patch, err := json.Marshal(map[string]interface{}{
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"annotations": map[string]string{
+							restartedAtAnnotation: time.Now().Format(time.RFC3339),
+						},
+					},
+				},
+			},
+		})
+		if err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		applyToObjects(t, "restart", objects, func(o objectRef) error {
+			client, err := resourceClient(r, o.namespace)
+			if err != nil {
+				return err
+			}
+			_, err = client.Patch(o.name, k8stypes.MergePatchType, patch, metav1.UpdateOptions{})
+			return err
+		})
+	})
+}
+
+// rolloutHistory is the DataSource of the history page of a workload.
+type rolloutHistory struct {
+	clientset *kubernetes.Clientset
+	resource  apiResource
+	namespace string
+	name      string
+
+	// lock guards the revisions, read by the event handler while the table refreshes
+	lock      sync.Mutex
+	revisions []revision
+	current   map[string]interface{}
+}
+
+func (h *rolloutHistory) Header() datafeeder.Row {
+	return datafeeder.Row{"REVISION", "CURRENT", "CHANGE-CAUSE", "AGE", "SOURCE"}
+}
+
+func (h *rolloutHistory) Data() []datafeeder.Row {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	currentYAML := templateYAML(h.current)
+	var rows []datafeeder.Row
+	for _, rev := range h.revisions {
+		current := ""
+		if templateYAML(rev.template) == currentYAML {
+			current = "*"
+		}
+		rows = append(rows, datafeeder.Row{fmt.Sprint(rev.number), current, rev.changeCause, since(rev.created), rev.source})
+	}
+	return rows
+}
+
+func (h *rolloutHistory) Refresh() error {
+	client, err := resourceClient(h.resource, h.namespace)
+	if err != nil {
+		return err
+	}
+	obj, err := client.Get(h.name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	revisions, err := workloadRevisions(h.clientset, h.resource, obj)
+	if err != nil {
+		return err
+	}
+	current, _, _ := unstructured.NestedMap(obj.Object, "spec", "template")
+	h.lock.Lock()
+	h.current, h.revisions = current, revisions
+	h.lock.Unlock()
+	return nil
+}
+
+func (h *rolloutHistory) currentTemplate() map[string]interface{} {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.current
+}
+
+func (h *rolloutHistory) revision(number string) (revision, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for _, rev := range h.revisions {
+		if fmt.Sprint(rev.number) == number {
+			return rev, true
+		}
+	}
+	return revision{}, false
+}
+
+/*
+workloadRevisions returns the revisions of a workload, newest first. Deployments keep them in their ReplicaSets,
+StatefulSets and DaemonSets in ControllerRevisions.
+*/
+func workloadRevisions(clientset *kubernetes.Clientset, r apiResource, obj *unstructured.Unstructured) ([]revision, error) {
+	var revisions []revision
+	switch r.kind {
+	case "Deployment":
+		list, err := clientset.AppsV1().ReplicaSets(obj.GetNamespace()).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, rs := range list.Items {
+			if !ownedBy(&rs, obj.GetUID()) {
+				continue
+			}
+			number, _ := strconv.ParseInt(rs.Annotations[revisionAnnotation], 10, 64)
+			template, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&rs.Spec.Template)
+			if err != nil {
+				return nil, err
+			}
+			unstructured.RemoveNestedField(template, "metadata", "labels", podTemplateHashLabel)
+			patch, err := json.Marshal([]map[string]interface{}{
+				{"op": "replace", "path": "/spec/template", "value": template},
+			})
+			if err != nil {
+				return nil, err
+			}
+			revisions = append(revisions, revision{
+				number:      number,
+				changeCause: rs.Annotations[changeCauseAnnotation],
+				created:     rs.CreationTimestamp,
+				source:      "ReplicaSet/" + rs.Name,
+				template:    template,
+				patchType:   k8stypes.JSONPatchType,
+				patch:       patch,
+			})
+		}
+	case "StatefulSet", "DaemonSet":
+		list, err := clientset.AppsV1().ControllerRevisions(obj.GetNamespace()).List(metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, cr := range list.Items {
+			if !ownedBy(&cr, obj.GetUID()) {
+				continue
+			}
+			// the data is the strategic merge patch of the template that the controller applies itself
+			data := map[string]interface{}{}
+			if err := json.Unmarshal(cr.Data.Raw, &data); err != nil {
+				return nil, err
+			}
+			template, _, _ := unstructured.NestedMap(data, "spec", "template")
+			unstructured.RemoveNestedField(template, "$patch")
+			revisions = append(revisions, revision{
+				number:      cr.Revision,
+				changeCause: cr.Annotations[changeCauseAnnotation],
+				created:     cr.CreationTimestamp,
+				source:      "ControllerRevision/" + cr.Name,
+				template:    template,
+				patchType:   k8stypes.StrategicMergePatchType,
+				patch:       cr.Data.Raw,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("%s has no rollout history", r.tableKind())
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].number > revisions[j].number
+	})
+	return revisions, nil
+}
+
+func templateYAML(template map[string]interface{}) string {
+	data, err := yaml.Marshal(template)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+func history(t *throwing.TableView) {
+	namespace, name := getNamespaceAndName(t)
+	r, err := lookupResource(t.GetClientSet(), t.GetResourceKind())
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	if !rolloutKinds[r.kind] {
+		t.UpdateStatus(fmt.Sprintf("%s has no rollout history", t.GetResourceKind()), true)
+		return
+	}
+
+	page := fmt.Sprintf("%s@%s/%s/%s", historyKind, r.tableKind(), namespace, name)
+	newtable := t.GetNestedTable(page)
+	if newtable == nil {
+		h := &rolloutHistory{
+			clientset: t.GetClientSet(),
+			resource:  r,
+			namespace: namespace,
+			name:      name,
+		}
+		kind := types.ResourceKind{
+			Title: fmt.Sprintf("rollout history - %s/%s", r.kind, name),
+			Kind:  historyKind,
+		}
+		newtable = t.NewNestTableView(kind, h, historyActions, nil, historyEventHandler(h))
+		t.SetTableView(page, newtable)
+	} else {
+		newtable.RefreshManual()
+	}
+	t.SwitchPage(page, newtable)
+}
+
+func historyEventHandler(h *rolloutHistory) throwing.EventHandler {
+	return func(t *throwing.TableView) func(event *tcell.EventKey) *tcell.EventKey {
+		return func(event *tcell.EventKey) *tcell.EventKey {
+			row, _ := t.GetTable().GetSelection()
+			rev, selected := h.revision(t.GetTable().GetCell(row, 0).Text)
+			switch event.Key() {
+			case tcell.KeyEnter:
+				if !selected {
+					return event
+				}
+				diff := unifiedDiff(templateYAML(h.currentTemplate()), templateYAML(rev.template), "current", fmt.Sprintf("revision %d", rev.number))
+				if diff == "" {
+					diff = fmt.Sprintf("revision %d is the current template", rev.number)
+				}
+				showText(t, "diff", fmt.Sprintf("diff - (%s revision %d)", h.name, rev.number), colorDiff(diff))
+			case tcell.KeyEscape:
+				t.BackPage()
+			case tcell.KeyRune:
+				switch event.Rune() {
+				case 'u':
+					if selected {
+						rollback(t, h, rev)
+					}
+				case 'r':
+					t.RefreshManual()
+				case 'q':
+					t.RootPage()
+				}
+			}
+			return event
+		}
+	}
+}
+
+func rollback(t *throwing.TableView, h *rolloutHistory, rev revision) {
+	confirm(t, "rollback", fmt.Sprintf("Do you want to roll %s %s back to revision %d?", h.resource.kind, h.name, rev.number), func() {
+		client, err := resourceClient(h.resource, h.namespace)
+		if err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		if _, err := client.Patch(h.name, rev.patchType, rev.patch, metav1.UpdateOptions{}); err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		t.SwitchToRootPage()
+		t.RefreshManual()
+	})
+}
@@ -0,0 +1,166 @@
+package k8s
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/tview"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+const (
+	// secretMask is shown in place of the values not revealed
+	secretMask = "********"
+)
+
+var (
+	// clipboardCommands are tried in order to copy to the clipboard, for macOS, Wayland and X
+	clipboardCommands = [][]string{
+		{"pbcopy"},
+		{"wl-copy"},
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+	}
+)
+
+// printable tells if a value is text that can be shown as is.
+func printable(value []byte) bool {
+	if !utf8.Valid(value) {
+		return false
+	}
+	for _, r := range string(value) {
+		if unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// valuePreview returns a value on one line, to fit in a cell.
+func valuePreview(value []byte) string {
+	if !printable(value) {
+		return fmt.Sprintf("<binary, %d bytes>", len(value))
+	}
+	lines := strings.Split(strings.TrimRight(string(value), "\n"), "\n")
+	if len(lines) > 1 {
+		return fmt.Sprintf("%s ... (%d lines)", lines[0], len(lines))
+	}
+	return lines[0]
+}
+
+// parseCertificates parses the PEM certificates of a value, other blocks such as keys are skipped.
+func parseCertificates(value []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, value = pem.Decode(value)
+		if block == nil {
+			return certs
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+}
+
+// certificateExpiry returns when the first certificate to expire does, the chain is only valid until then.
+func certificateExpiry(certs []*x509.Certificate) string {
+	notAfter := certs[0].NotAfter
+	for _, cert := range certs[1:] {
+		if cert.NotAfter.Before(notAfter) {
+			notAfter = cert.NotAfter
+		}
+	}
+	left := time.Until(notAfter)
+	if left < 0 {
+		return fmt.Sprintf("expired %s ago", duration.HumanDuration(-left))
+	}
+	return "in " + duration.HumanDuration(left)
+}
+
+func certificateDetails(certs []*x509.Certificate) string {
+	b := &strings.Builder{}
+	for i, cert := range certs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		var sans []string
+		sans = append(sans, cert.DNSNames...)
+		for _, ip := range cert.IPAddresses {
+			sans = append(sans, ip.String())
+		}
+		sans = append(sans, cert.EmailAddresses...)
+		for _, uri := range cert.URIs {
+			sans = append(sans, uri.String())
+		}
+		if len(sans) == 0 {
+			sans = []string{"<none>"}
+		}
+
+		color := "green"
+		if time.Now().After(cert.NotAfter) {
+			color = "red"
+		} else if time.Until(cert.NotAfter) < 30*24*time.Hour {
+			color = "yellow"
+		}
+		fmt.Fprintf(b, "[purple]Certificate %d of %d[white]\n", i+1, len(certs))
+		fmt.Fprintf(b, "Subject:    %s\n", tview.Escape(cert.Subject.String()))
+		fmt.Fprintf(b, "SANs:       %s\n", tview.Escape(strings.Join(sans, ", ")))
+		fmt.Fprintf(b, "Issuer:     %s\n", tview.Escape(cert.Issuer.String()))
+		fmt.Fprintf(b, "Serial:     %s\n", cert.SerialNumber.String())
+		fmt.Fprintf(b, "CA:         %v\n", cert.IsCA)
+		fmt.Fprintf(b, "Not before: %s\n", cert.NotBefore.Format(time.RFC3339))
+		fmt.Fprintf(b, "Not after:  [%s]%s (%s)[white]\n", color, cert.NotAfter.Format(time.RFC3339), certificateExpiry([]*x509.Certificate{cert}))
+	}
+	return b.String()
+}
+
+/*
+secretExpiry returns when the certificates of a secret, as listed in a table, expire first. Its data is still encoded
+in base64 there.
+*/
+func secretExpiry(obj *unstructured.Unstructured) string {
+	data, _, _ := unstructured.NestedStringMap(obj.Object, "data")
+	var certs []*x509.Certificate
+	for _, encoded := range data {
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			continue
+		}
+		certs = append(certs, parseCertificates(value)...)
+	}
+	if len(certs) == 0 {
+		return "-"
+	}
+	return certificateExpiry(certs)
+}
+
+// copyToClipboard copies text with the first clipboard command found.
+func copyToClipboard(text []byte) error {
+	for _, command := range clipboardCommands {
+		path, err := exec.LookPath(command[0])
+		if err != nil {
+			continue
+		}
+		cmd := exec.Command(path, command[1:]...)
+		// the output is not read, xclip stays in the background to serve the selection and would keep it open
+		cmd.Stdin = bytes.NewReader(text)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s: %v", command[0], err)
+		}
+		return nil
+	}
+	return fmt.Errorf("no clipboard command found, install one of pbcopy, wl-copy, xclip or xsel")
+}
@@ -0,0 +1,144 @@
+package k8s
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// makeCertificate returns a self-signed certificate in PEM, with its key first as a secret of type tls would hold both.
+func makeCertificate(t *testing.T, name string, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+}
+
+func TestValuePreview(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "hello", want: "hello"},
+		{value: "a\nb\nc\n", want: "a ... (3 lines)"},
+		{value: "line\n", want: "line"},
+		{value: "\x00\x01\x02", want: "<binary, 3 bytes>"},
+		{value: "\xff\xfe", want: "<binary, 2 bytes>"},
+	}
+	for _, tt := range tests {
+		if got := valuePreview([]byte(tt.value)); got != tt.want {
+			t.Errorf("valuePreview(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseCertificates(t *testing.T) {
+	now := time.Now()
+	soon := makeCertificate(t, "soon.example.com", now.Add(10*24*time.Hour))
+	later := makeCertificate(t, "later.example.com", now.Add(100*24*time.Hour))
+	expired := makeCertificate(t, "expired.example.com", now.Add(-48*time.Hour))
+
+	tests := []struct {
+		name       string
+		value      []byte
+		wantNames  []string
+		wantExpiry string
+	}{
+		{
+			name:  "not PEM",
+			value: []byte("password"),
+		},
+		{
+			name:       "key and certificate",
+			value:      later,
+			wantNames:  []string{"later.example.com"},
+			wantExpiry: "in 99d",
+		},
+		{
+			name:       "chain expires with its first certificate",
+			value:      append(append([]byte{}, later...), soon...),
+			wantNames:  []string{"later.example.com", "soon.example.com"},
+			wantExpiry: "in 9d",
+		},
+		{
+			name:       "expired",
+			value:      expired,
+			wantNames:  []string{"expired.example.com"},
+			wantExpiry: "expired 2d ago",
+		},
+	}
+	for _, tt := range tests {
+		certs := parseCertificates(tt.value)
+		var names []string
+		for _, cert := range certs {
+			names = append(names, cert.Subject.CommonName)
+		}
+		if !reflect.DeepEqual(names, tt.wantNames) {
+			t.Errorf("%s: parseCertificates() = %v, want %v", tt.name, names, tt.wantNames)
+			continue
+		}
+		if len(certs) == 0 {
+			continue
+		}
+		if got := certificateExpiry(certs); got != tt.wantExpiry {
+			t.Errorf("%s: certificateExpiry() = %q, want %q", tt.name, got, tt.wantExpiry)
+		}
+		if details := certificateDetails(certs); !strings.Contains(details, "SANs:       "+tt.wantNames[0]) {
+			t.Errorf("%s: certificateDetails() = %q, want the SANs of %s", tt.name, details, tt.wantNames[0])
+		}
+	}
+}
+
+func TestSecretExpiry(t *testing.T) {
+	cert := makeCertificate(t, "web", time.Now().Add(10*24*time.Hour))
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		want string
+	}{
+		{
+			name: "no certificate",
+			data: map[string]interface{}{"password": base64.StdEncoding.EncodeToString([]byte("x"))},
+			want: "-",
+		},
+		{
+			name: "tls",
+			data: map[string]interface{}{"tls.crt": base64.StdEncoding.EncodeToString(cert), "tls.key": "not base64!"},
+			want: "in 9d",
+		},
+	}
+	for _, tt := range tests {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{"data": tt.data}}
+		if got := secretExpiry(obj); got != tt.want {
+			t.Errorf("%s: secretExpiry() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package k8s
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+/*
+cronJob is a CronJob in whatever version of batch the server serves. The typed client of this version only knows
+batch/v1beta1, which current clusters no longer serve, so CronJobs are listed through the dynamic client and only the
+fields that did not change between versions are read. Ingresses are read the same way.
+*/
+type cronJob struct {
+	metav1.ObjectMeta
+	tableKind string
+	suspended bool
+	podSpec   corev1.PodSpec
+}
+
+// ingress is an Ingress in whatever version of networking or extensions the server serves.
+type ingress struct {
+	metav1.ObjectMeta
+	tableKind string
+	// services are the backends of the default backend and the rules
+	services []string
+	// secrets are the TLS secrets
+	secrets []string
+}
+
+func listCronJobs(clientset *kubernetes.Clientset, namespace string, listOptions metav1.ListOptions) ([]cronJob, error) {
+	r, items, err := listServed(clientset, []string{"cronjobs.batch"}, namespace, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	var cronJobs []cronJob
+	for _, item := range items {
+		c := cronJob{tableKind: r.tableKind()}
+		if err := fromUnstructured(item.Object, &c.ObjectMeta, "metadata"); err != nil {
+			return nil, err
+		}
+		if err := fromUnstructured(item.Object, &c.podSpec, "spec", "jobTemplate", "spec", "template", "spec"); err != nil {
+			return nil, err
+		}
+		c.suspended, _, _ = unstructured.NestedBool(item.Object, "spec", "suspend")
+		cronJobs = append(cronJobs, c)
+	}
+	return cronJobs, nil
+}
+
+func listIngresses(clientset *kubernetes.Clientset, namespace string, listOptions metav1.ListOptions) ([]ingress, error) {
+	r, items, err := listServed(clientset, []string{"ingresses.networking.k8s.io", "ingresses.extensions"}, namespace, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	var ingresses []ingress
+	for _, item := range items {
+		i := ingress{tableKind: r.tableKind()}
+		if err := fromUnstructured(item.Object, &i.ObjectMeta, "metadata"); err != nil {
+			return nil, err
+		}
+		// networking.k8s.io/v1 has spec.defaultBackend.service.name, the beta versions spec.backend.serviceName
+		if backend, ok, _ := unstructured.NestedMap(item.Object, "spec", "defaultBackend"); ok {
+			i.services = append(i.services, backendService(backend))
+		}
+		if backend, ok, _ := unstructured.NestedMap(item.Object, "spec", "backend"); ok {
+			i.services = append(i.services, backendService(backend))
+		}
+		rules, _, _ := unstructured.NestedSlice(item.Object, "spec", "rules")
+		for _, rule := range rules {
+			rule, _ := rule.(map[string]interface{})
+			paths, _, _ := unstructured.NestedSlice(rule, "http", "paths")
+			for _, path := range paths {
+				path, _ := path.(map[string]interface{})
+				if backend, ok, _ := unstructured.NestedMap(path, "backend"); ok {
+					i.services = append(i.services, backendService(backend))
+				}
+			}
+		}
+		tls, _, _ := unstructured.NestedSlice(item.Object, "spec", "tls")
+		for _, t := range tls {
+			t, _ := t.(map[string]interface{})
+			if name, _, _ := unstructured.NestedString(t, "secretName"); name != "" {
+				i.secrets = append(i.secrets, name)
+			}
+		}
+		ingresses = append(ingresses, i)
+	}
+	return ingresses, nil
+}
+
+func backendService(backend map[string]interface{}) string {
+	if name, ok, _ := unstructured.NestedString(backend, "service", "name"); ok {
+		return name
+	}
+	name, _, _ := unstructured.NestedString(backend, "serviceName")
+	return name
+}
+
+/*
+listServed lists the first of the resources the server serves. No objects are returned if it serves none of them, but
+any other error, including a failed discovery, is returned, so that callers never take a kind they could not list as
+empty.
+*/
+func listServed(clientset *kubernetes.Clientset, resources []string, namespace string, listOptions metav1.ListOptions) (apiResource, []unstructured.Unstructured, error) {
+	for _, resource := range resources {
+		r, err := lookupResource(clientset, resource)
+		if _, ok := err.(notServedError); ok {
+			continue
+		} else if err != nil {
+			return apiResource{}, nil, err
+		}
+		client, err := resourceClient(r, namespace)
+		if err != nil {
+			return apiResource{}, nil, err
+		}
+		list, err := client.List(listOptions)
+		if err != nil {
+			return apiResource{}, nil, err
+		}
+		return r, list.Items, nil
+	}
+	return apiResource{}, nil, nil
+}
+
+func fromUnstructured(obj map[string]interface{}, into interface{}, fields ...string) error {
+	value, ok, err := unstructured.NestedMap(obj, fields...)
+	if err != nil || !ok {
+		return err
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(value, into)
+}
@@ -0,0 +1,195 @@
+package k8s
+
+import (
+	"strings"
+
+	"github.com/rancher/axe/throwing"
+	"github.com/rivo/tview"
+)
+
+// manifestTemplate is a skeleton manifest, NAME and NAMESPACE are replaced by what is entered in the picker.
+type manifestTemplate struct {
+	kind     string
+	manifest string
+}
+
+var (
+	manifestTemplates = []manifestTemplate{
+		{"Deployment", `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: NAME
+  namespace: NAMESPACE
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: NAME
+  template:
+    metadata:
+      labels:
+        app: NAME
+    spec:
+      containers:
+      - name: NAME
+        image: nginx
+        ports:
+        - containerPort: 80
+`},
+		{"Service", `apiVersion: v1
+kind: Service
+metadata:
+  name: NAME
+  namespace: NAMESPACE
+spec:
+  selector:
+    app: NAME
+  ports:
+  - port: 80
+    targetPort: 80
+`},
+		{"ConfigMap", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: NAME
+  namespace: NAMESPACE
+data:
+  key: value
+`},
+		{"Secret", `apiVersion: v1
+kind: Secret
+metadata:
+  name: NAME
+  namespace: NAMESPACE
+type: Opaque
+stringData:
+  key: value
+`},
+		{"Job", `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: NAME
+  namespace: NAMESPACE
+spec:
+  backoffLimit: 3
+  template:
+    spec:
+      restartPolicy: Never
+      containers:
+      - name: NAME
+        image: busybox
+        command: ["sh", "-c", "echo hello"]
+`},
+		{"CronJob", `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: NAME
+  namespace: NAMESPACE
+spec:
+  schedule: "*/5 * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: OnFailure
+          containers:
+          - name: NAME
+            image: busybox
+            command: ["sh", "-c", "echo hello"]
+`},
+		{"PersistentVolumeClaim", `apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: NAME
+  namespace: NAMESPACE
+spec:
+  accessModes:
+  - ReadWriteOnce
+  resources:
+    requests:
+      storage: 1Gi
+`},
+		{"Ingress", `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: NAME
+  namespace: NAMESPACE
+spec:
+  rules:
+  - host: NAME.example.com
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: NAME
+            port:
+              number: 80
+`},
+		{"ServiceAccount", `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: NAME
+  namespace: NAMESPACE
+`},
+		{"Namespace", `apiVersion: v1
+kind: Namespace
+metadata:
+  name: NAME
+`},
+	}
+)
+
+func (m manifestTemplate) render(name, namespace string) string {
+	return strings.NewReplacer("NAMESPACE", namespace, "NAME", name).Replace(m.manifest)
+}
+
+/*
+templateDialog picks a skeleton manifest, pre-filled with the namespace of the current row. It is opened in the editor
+of the user, then goes through the preview of apply like any manifest.
+*/
+func templateDialog(t *throwing.TableView) {
+	var kinds []string
+	for _, m := range manifestTemplates {
+		kinds = append(kinds, m.kind)
+	}
+	selected, name, namespace := 0, "", currentNamespace(t)
+
+	form := tview.NewForm()
+	form.SetBorder(true).SetTitle("new - from template")
+	form.AddDropDown("Kind", kinds, 0, func(option string, index int) {
+		selected = index
+	})
+	form.AddInputField("Name", "", 30, nil, func(text string) {
+		name = strings.TrimSpace(text)
+	})
+	form.AddInputField("Namespace", namespace, 30, nil, func(text string) {
+		namespace = strings.TrimSpace(text)
+	})
+	form.AddButton("edit", func() {
+		if name == "" {
+			t.UpdateStatus("a name is required", true)
+			return
+		}
+		t.BackPage()
+		text, err := editText(t, name, manifestTemplates[selected].render(name, namespace))
+		if err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		objects, err := parseManifests([]byte(text))
+		if err != nil {
+			t.UpdateStatus(err.Error(), true)
+			return
+		}
+		previewApply(t, "new "+manifestTemplates[selected].kind, objects)
+	})
+	form.AddButton("Cancel", func() {
+		t.BackPage()
+	})
+	form.SetCancelFunc(func() {
+		t.BackPage()
+	})
+	t.InsertDialog("template", t.GetCurrentPrimitive(), form)
+}
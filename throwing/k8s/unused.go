@@ -0,0 +1,421 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/rancher/axe/throwing"
+	"github.com/rancher/axe/throwing/datafeeder"
+	"github.com/rancher/axe/throwing/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	unusedKind = "unused"
+
+	// maxDeletesListed is how many objects a deletion confirmation names
+	maxDeletesListed = 15
+)
+
+var (
+	unusedResourceKind = types.ResourceKind{
+		Title: "Unused resources",
+		Kind:  unusedKind,
+	}
+
+	unusedActions = []types.Action{
+		{
+			Name:        "mark",
+			Shortcut:    "Space",
+			Description: "mark or unmark the object",
+		},
+		{
+			Name:        "mark all",
+			Shortcut:    "M",
+			Description: "mark every object listed",
+		},
+		{
+			Name:        "delete marked",
+			Shortcut:    "d",
+			Description: "delete the marked objects",
+		},
+	}
+
+	// completedJobAge is how long a completed job is kept before it is reported, set with --completed-job-age
+	completedJobAge = 24 * time.Hour
+
+	// systemNamespaces hold the objects of the cluster itself, they are never reported
+	systemNamespaces = map[string]bool{
+		"kube-system":     true,
+		"kube-public":     true,
+		"kube-node-lease": true,
+	}
+
+	// unusedSecretTypes are secrets that pods do not reference, they are used by the cluster or by tools
+	unusedSecretTypes = map[corev1.SecretType]bool{
+		corev1.SecretTypeServiceAccountToken: true,
+		"helm.sh/release":                    true,
+		"helm.sh/release.v1":                 true,
+	}
+)
+
+// unusedObject is an object of the report with the reason it was flagged.
+type unusedObject struct {
+	kind      string
+	namespace string
+	name      string
+	reason    string
+}
+
+/*
+unusedReport is the DataSource of the unused resources page. Its rows are computed from several LIST calls rather than
+read from a single resource, and are kept so that the report can be acted on.
+*/
+type unusedReport struct {
+	clientset *kubernetes.Clientset
+	objects   []unusedObject
+}
+
+func (u *unusedReport) Header() datafeeder.Row {
+	return datafeeder.Row{"NAMESPACE", "NAME", "RESOURCE", "REASON"}
+}
+
+func (u *unusedReport) Data() []datafeeder.Row {
+	var rows []datafeeder.Row
+	for _, o := range u.objects {
+		rows = append(rows, datafeeder.Row{o.namespace, o.name, o.kind, o.reason})
+	}
+	return rows
+}
+
+func (u *unusedReport) Refresh() error {
+	var objects []unusedObject
+	flag := func(kind string, meta metav1.ObjectMeta, reason string) {
+		if systemNamespaces[meta.Namespace] {
+			return
+		}
+		objects = append(objects, unusedObject{
+			kind:      kind,
+			namespace: meta.Namespace,
+			name:      meta.Name,
+			reason:    reason,
+		})
+	}
+	listOptions := metav1.ListOptions{}
+
+	used, err := referencedObjects(u.clientset)
+	if err != nil {
+		return err
+	}
+
+	configMaps, err := u.clientset.CoreV1().ConfigMaps("").List(listOptions)
+	if err != nil {
+		return err
+	}
+	for _, c := range configMaps.Items {
+		if !used["configmaps/"+c.Namespace+"/"+c.Name] {
+			flag("configmaps", c.ObjectMeta, "not referenced by any pod")
+		}
+	}
+
+	secrets, err := u.clientset.CoreV1().Secrets("").List(listOptions)
+	if err != nil {
+		return err
+	}
+	for _, s := range secrets.Items {
+		if !unusedSecretTypes[s.Type] && !used["secrets/"+s.Namespace+"/"+s.Name] {
+			flag("secrets", s.ObjectMeta, "not referenced by any pod, service account or ingress")
+		}
+	}
+
+	claims, err := u.clientset.CoreV1().PersistentVolumeClaims("").List(listOptions)
+	if err != nil {
+		return err
+	}
+	for _, c := range claims.Items {
+		if !used["persistentvolumeclaims/"+c.Namespace+"/"+c.Name] {
+			flag("persistentvolumeclaims", c.ObjectMeta, "not mounted by any pod")
+		}
+	}
+
+	endpoints, err := u.clientset.CoreV1().Endpoints("").List(listOptions)
+	if err != nil {
+		return err
+	}
+	ready := map[string]bool{}
+	for _, e := range endpoints.Items {
+		for _, subset := range e.Subsets {
+			if len(subset.Addresses) > 0 {
+				ready[e.Namespace+"/"+e.Name] = true
+			}
+		}
+	}
+	services, err := u.clientset.CoreV1().Services("").List(listOptions)
+	if err != nil {
+		return err
+	}
+	for _, s := range services.Items {
+		if s.Spec.Type != corev1.ServiceTypeExternalName && !ready[s.Namespace+"/"+s.Name] {
+			flag("services", s.ObjectMeta, "no ready endpoints")
+		}
+	}
+
+	replicaSets, err := u.clientset.AppsV1().ReplicaSets("").List(listOptions)
+	if err != nil {
+		return err
+	}
+	for _, rs := range replicaSets.Items {
+		if rs.Spec.Replicas != nil && *rs.Spec.Replicas == 0 && len(rs.OwnerReferences) == 0 {
+			flag("replicasets.apps", rs.ObjectMeta, "scaled to zero without owner")
+		}
+	}
+
+	jobs, err := u.clientset.BatchV1().Jobs("").List(listOptions)
+	if err != nil {
+		return err
+	}
+	for _, j := range jobs.Items {
+		if j.Status.CompletionTime != nil && time.Since(j.Status.CompletionTime.Time) > completedJobAge {
+			flag("jobs.batch", j.ObjectMeta, fmt.Sprintf("completed %s ago", since(*j.Status.CompletionTime)))
+		}
+	}
+
+	sort.SliceStable(objects, func(i, j int) bool {
+		return objects[i].namespace < objects[j].namespace
+	})
+	u.objects = objects
+	return nil
+}
+
+/*
+referencedObjects returns the config maps, secrets and claims referenced in the cluster, keyed by resource/namespace/name.
+Pods and the pod templates of workloads are scanned, as well as the secrets of service accounts and of ingresses. Any list
+that fails fails the report, an object is never reported unused because a kind that may reference it could not be read.
+*/
+func referencedObjects(clientset *kubernetes.Clientset) (map[string]bool, error) {
+	used := map[string]bool{}
+	addSpec := func(namespace string, spec corev1.PodSpec) {
+		for _, kind := range []string{"configmaps", "secrets", "persistentvolumeclaims"} {
+			for _, name := range podSpecNames(spec, kind) {
+				used[kind+"/"+namespace+"/"+name] = true
+			}
+		}
+	}
+	listOptions := metav1.ListOptions{}
+
+	pods, err := clientset.CoreV1().Pods("").List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pods.Items {
+		addSpec(p.Namespace, p.Spec)
+	}
+
+	deployments, err := clientset.AppsV1().Deployments("").List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deployments.Items {
+		addSpec(d.Namespace, d.Spec.Template.Spec)
+	}
+
+	statefulSets, err := clientset.AppsV1().StatefulSets("").List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range statefulSets.Items {
+		addSpec(s.Namespace, s.Spec.Template.Spec)
+		// claims created from volume claim templates are named <template>-<statefulset>-<ordinal>
+		for _, template := range s.Spec.VolumeClaimTemplates {
+			prefix := template.Name + "-" + s.Name + "-"
+			for i := int32(0); s.Spec.Replicas != nil && i < *s.Spec.Replicas; i++ {
+				used[fmt.Sprintf("persistentvolumeclaims/%s/%s%d", s.Namespace, prefix, i)] = true
+			}
+		}
+	}
+
+	daemonSets, err := clientset.AppsV1().DaemonSets("").List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, ds := range daemonSets.Items {
+		addSpec(ds.Namespace, ds.Spec.Template.Spec)
+	}
+
+	cronJobs, err := listCronJobs(clientset, "", listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cronJobs {
+		addSpec(c.Namespace, c.podSpec)
+	}
+
+	accounts, err := clientset.CoreV1().ServiceAccounts("").List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range accounts.Items {
+		for _, s := range a.Secrets {
+			used["secrets/"+a.Namespace+"/"+s.Name] = true
+		}
+		for _, s := range a.ImagePullSecrets {
+			used["secrets/"+a.Namespace+"/"+s.Name] = true
+		}
+	}
+
+	ingresses, err := listIngresses(clientset, "", listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range ingresses {
+		for _, secret := range i.secrets {
+			used["secrets/"+i.Namespace+"/"+secret] = true
+		}
+	}
+	return used, nil
+}
+
+// podSpecNames returns the names of the objects of a kind that a pod spec references.
+func podSpecNames(spec corev1.PodSpec, kind string) []string {
+	var names []string
+	for _, v := range spec.Volumes {
+		switch {
+		case kind == "configmaps" && v.ConfigMap != nil:
+			names = append(names, v.ConfigMap.Name)
+		case kind == "secrets" && v.Secret != nil:
+			names = append(names, v.Secret.SecretName)
+		case kind == "persistentvolumeclaims" && v.PersistentVolumeClaim != nil:
+			names = append(names, v.PersistentVolumeClaim.ClaimName)
+		case v.Projected != nil:
+			for _, source := range v.Projected.Sources {
+				if kind == "configmaps" && source.ConfigMap != nil {
+					names = append(names, source.ConfigMap.Name)
+				}
+				if kind == "secrets" && source.Secret != nil {
+					names = append(names, source.Secret.Name)
+				}
+			}
+		}
+	}
+	if kind == "persistentvolumeclaims" {
+		return names
+	}
+	for _, c := range append(spec.InitContainers, spec.Containers...) {
+		for _, from := range c.EnvFrom {
+			if kind == "configmaps" && from.ConfigMapRef != nil {
+				names = append(names, from.ConfigMapRef.Name)
+			}
+			if kind == "secrets" && from.SecretRef != nil {
+				names = append(names, from.SecretRef.Name)
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if kind == "configmaps" && env.ValueFrom.ConfigMapKeyRef != nil {
+				names = append(names, env.ValueFrom.ConfigMapKeyRef.Name)
+			}
+			if kind == "secrets" && env.ValueFrom.SecretKeyRef != nil {
+				names = append(names, env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+	if kind == "secrets" {
+		for _, s := range spec.ImagePullSecrets {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+func unusedEventHandler(t *throwing.TableView) func(event *tcell.EventKey) *tcell.EventKey {
+	return func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			row, _ := t.GetTable().GetSelection()
+			namespace, name := getNamespaceAndName(t)
+			showObject(t, t.GetTable().GetCell(row, 2).Text, namespace, name)
+		case tcell.KeyEscape:
+			t.BackPage()
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case ' ':
+				t.ToggleMark()
+			case 'M':
+				t.MarkAll()
+			case 'm':
+				markDialog(t)
+			case 'd':
+				deleteUnused(t)
+			case 'r':
+				t.RefreshManual()
+			case 'q':
+				t.RootPage()
+			case '/':
+				t.ShowSearch()
+			}
+		}
+		return event
+	}
+}
+
+func viewUnused(t *throwing.TableView) {
+	newtable := t.GetNestedTable(unusedKind)
+	if newtable == nil {
+		report := &unusedReport{clientset: t.GetClientSet()}
+		newtable = t.NewNestTableView(unusedResourceKind, report, unusedActions, nil, unusedEventHandler)
+		t.SetTableView(unusedKind, newtable)
+	} else {
+		newtable.RefreshManual()
+	}
+	t.SwitchPage(unusedKind, newtable)
+}
+
+// deleteUnused deletes the marked objects once confirmed, and reports the objects that could not be deleted.
+func deleteUnused(t *throwing.TableView) {
+	var objects []unusedObject
+	table := t.GetTable()
+	for _, row := range t.MarkedRows() {
+		objects = append(objects, unusedObject{
+			namespace: table.GetCell(row, 0).Text,
+			name:      table.GetCell(row, 1).Text,
+			kind:      table.GetCell(row, 2).Text,
+		})
+	}
+	if len(objects) == 0 {
+		t.UpdateStatus("mark the objects to delete with space, or all of them with M", true)
+		return
+	}
+
+	var names []string
+	for i, o := range objects {
+		if i == maxDeletesListed {
+			names = append(names, fmt.Sprintf("and %d more", len(objects)-i))
+			break
+		}
+		names = append(names, fmt.Sprintf("%s %s/%s", o.kind, o.namespace, o.name))
+	}
+	text := fmt.Sprintf("Do you want to delete the %d marked objects?\n\n%s", len(objects), strings.Join(names, "\n"))
+	confirm(t, "delete", text, func() {
+		t.BackPage()
+		var failed []string
+		for _, o := range objects {
+			if err := deleteObject(t.GetClientSet(), o.kind, o.namespace, o.name, &metav1.DeleteOptions{}); err != nil {
+				failed = append(failed, fmt.Sprintf("%s/%s: %v", o.kind, o.name, err))
+			}
+		}
+		t.ClearMarks()
+		t.RefreshManual()
+		if len(failed) > 0 {
+			t.UpdateStatus(fmt.Sprintf("%d of %d deleted\n%s", len(objects)-len(failed), len(objects), strings.Join(failed, "\n")), true)
+			return
+		}
+		t.UpdateStatus(fmt.Sprintf("%d objects deleted", len(objects)), false)
+	})
+}
@@ -0,0 +1,324 @@
+package k8s
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/rancher/axe/throwing"
+	"github.com/rancher/axe/throwing/datafeeder"
+	"github.com/rancher/axe/throwing/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	usedByKind = "usedby"
+
+	maxUsagesShown = 5
+)
+
+var (
+	// usedByKinds are the kinds that pod specs reference by name
+	usedByKinds = map[string]bool{
+		"configmaps":             true,
+		"secrets":                true,
+		"persistentvolumeclaims": true,
+		"serviceaccounts":        true,
+	}
+)
+
+// usage is an object whose pod spec references the object looked up, with where in the spec it does.
+type usage struct {
+	kind      string
+	tableKind string
+	namespace string
+	name      string
+	via       []string
+}
+
+func usedBy(t *throwing.TableView) {
+	kind := t.GetResourceKind()
+	if !usedByKinds[kind] {
+		return
+	}
+	namespace, name := getNamespaceAndName(t)
+
+	rkind := types.ResourceKind{
+		Title: fmt.Sprintf("used by - %s/%s", kind, name),
+		Kind:  usedByKind,
+	}
+	page := fmt.Sprintf("%s@%s/%s/%s", usedByKind, kind, namespace, name)
+	refresh := func(b *bytes.Buffer) error {
+		usages, err := findUsages(t.GetClientSet(), kind, namespace, name)
+		if err != nil {
+			return err
+		}
+		b.WriteString("NAMESPACE\tNAME\tKIND\tRESOURCE\tREFERENCED BY")
+		for _, u := range usages {
+			fmt.Fprintf(b, "\n%s\t%s\t%s\t%s\t%s", u.namespace, u.name, u.kind, u.tableKind, strings.Join(u.via, ", "))
+		}
+		return nil
+	}
+
+	newtable := t.GetNestedTable(page)
+	if newtable == nil {
+		newtable = t.NewNestTableView(rkind, datafeeder.NewDataFeeder(refresh), nil, nil, usedByEventHandler)
+		t.SetTableView(page, newtable)
+	} else {
+		newtable.RefreshManual()
+	}
+	t.SwitchPage(page, newtable)
+}
+
+// usedByEventHandler is a function for the same reason as itemEventHandler: the objects it navigates to lead back here.
+func usedByEventHandler(t *throwing.TableView) func(event *tcell.EventKey) *tcell.EventKey {
+	return func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			row, _ := t.GetTable().GetSelection()
+			namespace, name := getNamespaceAndName(t)
+			showObject(t, t.GetTable().GetCell(row, 3).Text, namespace, name)
+		case tcell.KeyEscape:
+			t.BackPage()
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'r':
+				t.RefreshManual()
+			case 'q':
+				t.RootPage()
+			}
+		}
+		return event
+	}
+}
+
+/*
+findUsages scans the pod specs of the pods and workloads in the namespace for references to a config map, secret,
+persistent volume claim or service account.
+*/
+func findUsages(clientset *kubernetes.Clientset, kind, namespace, name string) ([]usage, error) {
+	specs, err := listPodSpecs(clientset, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return usagesOf(specs, kind, namespace, name), nil
+}
+
+// podSpecSource is an object with a pod spec, a pod or the pod template of a workload.
+type podSpecSource struct {
+	kind      string
+	tableKind string
+	meta      metav1.ObjectMeta
+	spec      corev1.PodSpec
+}
+
+// usagesOf returns the usages of an object among pod specs, which may be of other namespaces too.
+func usagesOf(specs []podSpecSource, kind, namespace, name string) []usage {
+	var usages []usage
+	for _, s := range specs {
+		if s.meta.Namespace != namespace {
+			continue
+		}
+		if via := podSpecReferences(s.spec, kind, name); len(via) > 0 {
+			usages = append(usages, usage{
+				kind:      s.kind,
+				tableKind: s.tableKind,
+				namespace: s.meta.Namespace,
+				name:      s.meta.Name,
+				via:       via,
+			})
+		}
+	}
+	return usages
+}
+
+/*
+listPodSpecs lists the pod specs of the pods and workloads in the namespace, or in all namespaces if empty, so that the
+usages of several objects are found with one list of each kind.
+*/
+func listPodSpecs(clientset *kubernetes.Clientset, namespace string) ([]podSpecSource, error) {
+	var specs []podSpecSource
+	add := func(kindName, tableKind string, meta metav1.ObjectMeta, spec corev1.PodSpec) {
+		specs = append(specs, podSpecSource{
+			kind:      kindName,
+			tableKind: tableKind,
+			meta:      meta,
+			spec:      spec,
+		})
+	}
+	listOptions := metav1.ListOptions{}
+
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deployments.Items {
+		add("Deployment", "deployments.apps", d.ObjectMeta, d.Spec.Template.Spec)
+	}
+
+	statefulSets, err := clientset.AppsV1().StatefulSets(namespace).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range statefulSets.Items {
+		add("StatefulSet", "statefulsets.apps", s.ObjectMeta, s.Spec.Template.Spec)
+	}
+
+	daemonSets, err := clientset.AppsV1().DaemonSets(namespace).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, ds := range daemonSets.Items {
+		add("DaemonSet", "daemonsets.apps", ds.ObjectMeta, ds.Spec.Template.Spec)
+	}
+
+	replicaSets, err := clientset.AppsV1().ReplicaSets(namespace).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, rs := range replicaSets.Items {
+		add("ReplicaSet", "replicasets.apps", rs.ObjectMeta, rs.Spec.Template.Spec)
+	}
+
+	jobs, err := clientset.BatchV1().Jobs(namespace).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, j := range jobs.Items {
+		add("Job", "jobs.batch", j.ObjectMeta, j.Spec.Template.Spec)
+	}
+
+	cronJobs, err := listCronJobs(clientset, namespace, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cronJobs {
+		add("CronJob", c.tableKind, c.ObjectMeta, c.podSpec)
+	}
+
+	pods, err := clientset.CoreV1().Pods(namespace).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pods.Items {
+		add("Pod", "pods", p.ObjectMeta, p.Spec)
+	}
+	return specs, nil
+}
+
+// podSpecReferences returns where a pod spec references the object, if it does.
+func podSpecReferences(spec corev1.PodSpec, kind, name string) []string {
+	var via []string
+	switch kind {
+	case "serviceaccounts":
+		account := spec.ServiceAccountName
+		if account == "" {
+			account = "default"
+		}
+		if account == name {
+			via = append(via, "serviceAccountName")
+		}
+		return via
+	case "persistentvolumeclaims":
+		for _, v := range spec.Volumes {
+			if v.PersistentVolumeClaim != nil && v.PersistentVolumeClaim.ClaimName == name {
+				via = append(via, "volume "+v.Name)
+			}
+		}
+		return via
+	}
+
+	secret := kind == "secrets"
+	for _, v := range spec.Volumes {
+		switch {
+		case secret && v.Secret != nil && v.Secret.SecretName == name:
+			via = append(via, "volume "+v.Name)
+		case !secret && v.ConfigMap != nil && v.ConfigMap.Name == name:
+			via = append(via, "volume "+v.Name)
+		case v.Projected != nil:
+			for _, source := range v.Projected.Sources {
+				if (secret && source.Secret != nil && source.Secret.Name == name) ||
+					(!secret && source.ConfigMap != nil && source.ConfigMap.Name == name) {
+					via = append(via, "volume "+v.Name)
+				}
+			}
+		}
+	}
+	for _, c := range append(spec.InitContainers, spec.Containers...) {
+		for _, from := range c.EnvFrom {
+			if (secret && from.SecretRef != nil && from.SecretRef.Name == name) ||
+				(!secret && from.ConfigMapRef != nil && from.ConfigMapRef.Name == name) {
+				via = append(via, "envFrom "+c.Name)
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if (secret && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == name) ||
+				(!secret && env.ValueFrom.ConfigMapKeyRef != nil && env.ValueFrom.ConfigMapKeyRef.Name == name) {
+				via = append(via, fmt.Sprintf("env %s/%s", c.Name, env.Name))
+			}
+		}
+	}
+	if secret {
+		for _, s := range spec.ImagePullSecrets {
+			if s.Name == name {
+				via = append(via, "imagePullSecrets")
+			}
+		}
+	}
+	return via
+}
+
+// usageSummary lists the first objects of usages, for the delete confirmation.
+func usageSummary(usages []usage) string {
+	var names []string
+	for i, u := range usages {
+		if i == maxUsagesShown {
+			names = append(names, fmt.Sprintf("and %d more", len(usages)-i))
+			break
+		}
+		names = append(names, u.kind+"/"+u.name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// showObject opens the table of a resource, as if picked on the root page, with the row of the object selected.
+func showObject(t *throwing.TableView, kind, namespace, name string) {
+	r, err := lookupResource(t.GetClientSet(), kind)
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	rkind := types.ResourceKind{
+		Title: kind,
+		Kind:  kind,
+	}
+
+	newtable := t.GetNestedTable(kind)
+	if newtable == nil {
+		w := wrapper{
+			group:   r.gvr.Group,
+			version: r.gvr.Version,
+			name:    r.gvr.Resource,
+		}
+		newtable = t.NewNestTableView(rkind, datafeeder.NewDataFeeder(w.refreshResource), nil, nil, itemEventHandler)
+		t.SetTableView(kind, newtable)
+	} else {
+		newtable.RefreshManual()
+	}
+	t.SwitchPage(kind, newtable)
+
+	table := newtable.GetTable()
+	for row := 1; row < table.GetRowCount(); row++ {
+		table.Select(row, 0)
+		if ns, n := getNamespaceAndName(newtable); ns == namespace && n == name {
+			return
+		}
+	}
+	table.Select(1, 0)
+}
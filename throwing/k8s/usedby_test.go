@@ -0,0 +1,64 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUsagesOf(t *testing.T) {
+	spec := corev1.PodSpec{
+		ServiceAccountName: "builder",
+		Volumes: []corev1.Volume{
+			{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "web"}}}},
+			{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "web"}}},
+		},
+		Containers: []corev1.Container{
+			{Name: "web", EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "web"}}}}},
+		},
+	}
+	specs := []podSpecSource{
+		{kind: "Deployment", tableKind: "deployments.apps", meta: metav1.ObjectMeta{Namespace: "default", Name: "web"}, spec: spec},
+		{kind: "Pod", tableKind: "pods", meta: metav1.ObjectMeta{Namespace: "other", Name: "web-1"}, spec: spec},
+		{kind: "Pod", tableKind: "pods", meta: metav1.ObjectMeta{Namespace: "default", Name: "idle"}},
+	}
+
+	tests := []struct {
+		kind      string
+		namespace string
+		name      string
+		want      []usage
+	}{
+		{
+			kind:      "configmaps",
+			namespace: "default",
+			name:      "web",
+			want:      []usage{{kind: "Deployment", tableKind: "deployments.apps", namespace: "default", name: "web", via: []string{"volume config"}}},
+		},
+		{
+			kind:      "persistentvolumeclaims",
+			namespace: "other",
+			name:      "web",
+			want:      []usage{{kind: "Pod", tableKind: "pods", namespace: "other", name: "web-1", via: []string{"volume data"}}},
+		},
+		{
+			kind:      "serviceaccounts",
+			namespace: "default",
+			name:      "default",
+			want:      []usage{{kind: "Pod", tableKind: "pods", namespace: "default", name: "idle", via: []string{"serviceAccountName"}}},
+		},
+		{
+			kind:      "configmaps",
+			namespace: "default",
+			name:      "unused",
+		},
+	}
+	for _, tt := range tests {
+		got := usagesOf(specs, tt.kind, tt.namespace, tt.name)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("usagesOf(%s, %s/%s) = %+v, want %+v", tt.kind, tt.namespace, tt.name, got, tt.want)
+		}
+	}
+}
@@ -13,16 +13,22 @@ import (
 	"github.com/rancher/norman/pkg/kv"
 	"github.com/rivo/tview"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
 func getNamespaceAndName(t *throwing.TableView) (string, string) {
+	row, _ := t.GetTable().GetSelection()
+	return rowNamespaceAndName(t, row)
+}
+
+func rowNamespaceAndName(t *throwing.TableView, row int) (string, string) {
 	table := t.GetTable()
 	namespaced := false
 	if strings.Contains(table.GetCell(0, 0).Text, "NAMESPACE") {
 		namespaced = true
 	}
 
-	row, _ := table.GetSelection()
 	var namespace, name string
 	if namespaced {
 		namespace, name = table.GetCell(row, 0).Text, table.GetCell(row, 1).Text
@@ -32,94 +38,56 @@ func getNamespaceAndName(t *throwing.TableView) (string, string) {
 	return namespace, name
 }
 
-func get(t *throwing.TableView) {
-	out := &strings.Builder{}
-	errB := &strings.Builder{}
+// objectRef is an object of the resource shown by a table.
+type objectRef struct {
+	namespace string
+	name      string
+}
 
-	var args []string
-	namespace, name := getNamespaceAndName(t)
-	if namespace != "" {
-		args = []string{"get", t.GetResourceKind(), "-n", namespace, name, "-o", "yaml"}
-	} else {
-		args = []string{"get", t.GetResourceKind(), name, "-o", "yaml"}
+// selectedObjects returns the objects the actions of a table apply to: the marked rows, or the current row if none is.
+func selectedObjects(t *throwing.TableView) []objectRef {
+	var objects []objectRef
+	for _, row := range t.MarkedRows() {
+		namespace, name := rowNamespaceAndName(t, row)
+		objects = append(objects, objectRef{namespace, name})
 	}
-	cmd := exec.Command("kubectl", args...)
-	cmd.Stdout, cmd.Stderr = out, errB
-	if err := cmd.Run(); err != nil {
-		t.UpdateStatus(errB.String(), true)
-		return
+	if len(objects) == 0 {
+		namespace, name := getNamespaceAndName(t)
+		objects = append(objects, objectRef{namespace, name})
 	}
-
-	box := tview.NewTextView()
-	box.SetDynamicColors(true).SetBackgroundColor(tcell.ColorBlack)
-	box.SetText(out.String())
-	box.SetDoneFunc(func(key tcell.Key) {
-		if key == tcell.KeyEscape {
-			t.SwitchToRootPage()
-		}
-	})
-
-	newpage := tview.NewPages().AddPage("get", box, true, true)
-	t.SwitchPage(t.GetCurrentPage(), newpage)
+	return objects
 }
 
-func edit(t *throwing.TableView) {
-	namespace, name := getNamespaceAndName(t)
-	errb := &strings.Builder{}
-	var args []string
-	if namespace != "" {
-		args = []string{"edit", t.GetResourceKind(), "-n", namespace, name}
-	} else {
-		args = []string{"edit", t.GetResourceKind(), name}
-	}
-	cmd := exec.Command("kubectl", args...)
-	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, errb
-
-	t.GetApplication().Suspend(func() {
-		clearScreen()
-		if err := cmd.Run(); err != nil {
-			t.UpdateStatus(errb.String(), true)
-		}
-		return
-	})
+func getRestConfig() (*rest.Config, error) {
+	return clientcmd.BuildConfigFromFlags("", os.Getenv("KUBECONFIG"))
 }
 
-func execute(t *throwing.TableView) {
+func logs(t *throwing.TableView) {
 	if t.GetResourceKind() != "pods" {
 		return
 	}
 
 	namespace, name := getNamespaceAndName(t)
-	errb := &strings.Builder{}
-	shellArgs := []string{"/bin/sh", "-c", "TERM=xterm-256color; export TERM; [ -x /bin/bash ] && ([ -x /usr/bin/script ] && /usr/bin/script -q -c /bin/bash /dev/null || exec /bin/bash) || exec /bin/sh"}
-	args := append([]string{"exec", "-it", "-n", namespace, name, "--"}, shellArgs...)
-	cmd := exec.Command("kubectl", args...)
-	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, errb
-
-	t.GetApplication().Suspend(func() {
-		clearScreen()
-		if err := cmd.Run(); err != nil {
-			t.UpdateStatus(errb.String(), true)
-		}
-		return
-	})
+	showLogs(t, namespace, name, "")
 }
 
-func logs(t *throwing.TableView) {
-	if t.GetResourceKind() != "pods" {
-		return
-	}
-
+// showLogs follows the logs of one container of a pod, or of all its containers if container is empty.
+func showLogs(t *throwing.TableView, namespace, name, container string) {
 	errB := &strings.Builder{}
-	var args []string
-	namespace, name := getNamespaceAndName(t)
-	args = []string{"logs", "-f", "-n", namespace, name, "--all-containers"}
+	args := []string{"logs", "-f", "-n", namespace, name, "--all-containers"}
+	if container != "" {
+		args = []string{"logs", "-f", "-n", namespace, name, "-c", container}
+		name += "/" + container
+	}
 	cmd := exec.Command("kubectl", args...)
 	cmd.Stderr = errB
 
 	logbox := tview.NewTextView()
+	formatter := newLogFormatter(logbox)
+	prompt := tview.NewInputField()
+	layout := tview.NewFlex().SetDirection(tview.FlexRow)
 	{
-		logbox.SetTitle(fmt.Sprintf("logs - (%s)", name))
+		logbox.SetTitle(fmt.Sprintf("logs - (%s) [f] fields [/] filter", name))
 		logbox.SetBorder(true)
 		logbox.SetTitleColor(tcell.ColorPurple)
 		logbox.SetDynamicColors(true)
@@ -133,16 +101,50 @@ func logs(t *throwing.TableView) {
 				cmd.Process.Kill()
 			}
 		})
+		logbox.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+			switch event.Rune() {
+			case 'f':
+				prompt.SetLabel(logFieldsLabel).SetText(formatter.Fields())
+			case '/':
+				prompt.SetLabel(logFiltersLabel).SetText(formatter.Filters())
+			default:
+				return event
+			}
+			t.GetApplication().SetFocus(prompt)
+			return nil
+		})
 	}
+	{
+		prompt.SetFieldBackgroundColor(tcell.ColorBlack)
+		prompt.SetFieldTextColor(tcell.ColorBlue)
+		prompt.SetDoneFunc(func(key tcell.Key) {
+			if key == tcell.KeyEnter {
+				if prompt.GetLabel() == logFieldsLabel {
+					formatter.SetFields(prompt.GetText())
+				} else {
+					formatter.SetFilters(prompt.GetText())
+				}
+				logbox.Clear()
+				if err := formatter.Render(); err != nil {
+					t.UpdateStatus(err.Error(), true)
+					return
+				}
+			}
+			prompt.SetLabel("").SetText("")
+			t.GetApplication().SetFocus(logbox)
+		})
+	}
+	layout.AddItem(logbox, 0, 1, true)
+	layout.AddItem(prompt, 1, 1, false)
 
-	cmd.Stdout = tview.ANSIWriter(logbox)
+	cmd.Stdout = formatter
 	go func() {
 		if err := cmd.Run(); err != nil {
 			return
 		}
 	}()
 
-	newpage := tview.NewPages().AddPage("logs", logbox, true, true)
+	newpage := tview.NewPages().AddPage("logs", layout, true, true)
 	t.SwitchPage(t.GetCurrentPage(), newpage)
 }
 
@@ -150,35 +152,8 @@ func clearScreen() {
 	fmt.Print("\033[H\033[2J")
 }
 
-func delete(t *throwing.TableView) {
-	namespace, name := getNamespaceAndName(t)
-	modal := tview.NewModal().
-		SetText(fmt.Sprintf("Do you want to delete %s %s?", t.GetResourceKind(), name)).
-		AddButtons([]string{"delete", "Cancel"}).
-		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-			if buttonLabel == "delete" {
-				var args []string
-				if namespace != "" {
-					args = []string{"delete", t.GetResourceKind(), "-n", namespace, name}
-				} else {
-					args = []string{"delete", t.GetResourceKind(), name}
-				}
-				cmd := exec.Command("kubectl", args...)
-				errB := &strings.Builder{}
-				cmd.Stderr = errB
-				go func() {
-					if err := cmd.Run(); err != nil {
-						t.UpdateStatus(errB.String(), true)
-						return
-					}
-				}()
-				t.Refresh()
-				t.SwitchToRootPage()
-			} else if buttonLabel == "Cancel" {
-				t.BackPage()
-			}
-		})
-	t.InsertDialog("delete", t.GetCurrentPrimitive(), modal)
+func deleteSelected(t *throwing.TableView) {
+	deleteDialog(t, selectedObjects(t))
 }
 
 func resourceView(t *throwing.TableView) error {
@@ -227,3 +202,37 @@ func viewResource(t *throwing.TableView) {
 
 	t.SwitchPage(rkind.Kind, newtable)
 }
+
+// confirm asks for a confirmation in a modal dialog and runs ok once given.
+func confirm(t *throwing.TableView, button, text string, ok func()) {
+	modal := tview.NewModal().
+		SetText(text).
+		AddButtons([]string{button, "Cancel"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			if buttonLabel == button {
+				ok()
+			} else {
+				t.BackPage()
+			}
+		})
+	t.InsertDialog(button, t.GetCurrentPrimitive(), modal)
+}
+
+// showText opens a page with a read-only text, with dynamic colors. The text view is returned to add keys to it.
+func showText(t *throwing.TableView, page, title, text string) *tview.TextView {
+	box := tview.NewTextView()
+	box.SetTitle(title)
+	box.SetBorder(true)
+	box.SetTitleColor(tcell.ColorPurple)
+	box.SetDynamicColors(true).SetBackgroundColor(tcell.ColorBlack)
+	box.SetText(text)
+	box.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape {
+			t.SwitchToRootPage()
+		}
+	})
+
+	newpage := tview.NewPages().AddPage(page, box, true, true)
+	t.SwitchPage(t.GetCurrentPage(), newpage)
+	return box
+}
@@ -0,0 +1,295 @@
+package k8s
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/rancher/axe/throwing"
+	"github.com/rivo/tview"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// timelineHeight is the height of the revision timeline under the object
+	timelineHeight = 8
+)
+
+// objectRevision is a version of an object seen while its page is open.
+type objectRevision struct {
+	resourceVersion string
+	seen            time.Time
+	manager         string
+	operation       string
+	manifest        string
+}
+
+/*
+objectWatch keeps the get page of an object live. Every version seen is kept in a timeline, along with the field
+manager that wrote it last, so that any two versions can be diffed.
+*/
+type objectWatch struct {
+	t      *throwing.TableView
+	client dynamic.ResourceInterface
+	name   string
+
+	lock      sync.Mutex
+	revisions []objectRevision
+	base      int
+	// stop is closed once the page is left, whichever way
+	stop <-chan struct{}
+
+	view     *tview.TextView
+	timeline *tview.Table
+	layout   *tview.Flex
+}
+
+func newObjectRevision(obj *unstructured.Unstructured) objectRevision {
+	rev := objectRevision{
+		resourceVersion: obj.GetResourceVersion(),
+		seen:            time.Now(),
+		manager:         "-",
+		operation:       "-",
+		manifest:        diffView(obj),
+	}
+	// the last writer is the manager with the most recent time
+	entries, _, _ := unstructured.NestedSlice(obj.Object, "metadata", "managedFields")
+	latest := ""
+	for _, e := range entries {
+		entry, ok := e.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if updated, _ := entry["time"].(string); updated >= latest {
+			latest = updated
+			rev.manager = fmt.Sprint(entry["manager"])
+			rev.operation = fmt.Sprint(entry["operation"])
+		}
+	}
+	return rev
+}
+
+func get(t *throwing.TableView) {
+	namespace, name := getNamespaceAndName(t)
+	r, err := lookupResource(t.GetClientSet(), t.GetResourceKind())
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	client, err := resourceClient(r, namespace)
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+	obj, err := client.Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.UpdateStatus(err.Error(), true)
+		return
+	}
+
+	w := &objectWatch{
+		t:      t,
+		client: client,
+		name:   name,
+		base:   -1,
+	}
+	w.init()
+	w.add(obj)
+
+	newpage := tview.NewPages().AddPage("get", w.layout, true, true)
+	t.SwitchPage(t.GetCurrentPage(), newpage)
+	w.stop = t.WhileShown(newpage)
+	go w.run(obj.GetResourceVersion())
+}
+
+func (w *objectWatch) init() {
+	w.view = tview.NewTextView()
+	w.view.SetBorder(true)
+	w.view.SetTitleColor(tcell.ColorPurple)
+	w.view.SetDynamicColors(true).SetBackgroundColor(tcell.ColorBlack)
+
+	w.timeline = tview.NewTable()
+	w.timeline.SetBorder(true)
+	w.timeline.SetTitle("revisions [Enter] diff with previous [m] mark as base [Tab] object")
+	w.timeline.SetBackgroundColor(tcell.ColorBlack)
+	w.timeline.SetSelectable(true, false)
+	w.timeline.SetFixed(1, 0)
+	w.timeline.SetSelectedFunc(func(row, column int) {
+		w.diff(row - 1)
+	})
+
+	w.layout = tview.NewFlex().SetDirection(tview.FlexRow)
+	w.layout.AddItem(w.view, 0, 1, true)
+	w.layout.AddItem(w.timeline, timelineHeight, 0, false)
+
+	app := w.t.GetApplication()
+	w.view.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyTab:
+			app.SetFocus(w.timeline)
+			return nil
+		case tcell.KeyEscape:
+			w.leave()
+			return nil
+		}
+		return event
+	})
+	w.timeline.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyTab:
+			w.showLive()
+			app.SetFocus(w.view)
+			return nil
+		case tcell.KeyEscape:
+			w.leave()
+			return nil
+		}
+		if event.Rune() == 'm' {
+			row, _ := w.timeline.GetSelection()
+			w.lock.Lock()
+			w.base = row - 1
+			w.lock.Unlock()
+			w.drawTimeline()
+			return nil
+		}
+		return event
+	})
+}
+
+// leave goes back to the table, which stops the watch.
+func (w *objectWatch) leave() {
+	w.t.SwitchToRootPage()
+}
+
+// run watches the object until the page is left, and watches again whenever the watch ends while the page is shown.
+func (w *objectWatch) run(resourceVersion string) {
+	for {
+		watcher, err := w.client.Watch(metav1.ListOptions{
+			FieldSelector:   fields.OneTermEqualSelector("metadata.name", w.name).String(),
+			ResourceVersion: resourceVersion,
+		})
+		if err != nil {
+			select {
+			case <-w.stop:
+				return
+			case <-time.After(eventRetryInterval):
+			}
+			continue
+		}
+		resourceVersion = w.consume(watcher, resourceVersion)
+		watcher.Stop()
+		select {
+		case <-w.stop:
+			return
+		default:
+		}
+	}
+}
+
+func (w *objectWatch) consume(watcher watch.Interface, resourceVersion string) string {
+	for {
+		select {
+		case <-w.stop:
+			return resourceVersion
+		case change, ok := <-watcher.ResultChan():
+			if !ok {
+				return resourceVersion
+			}
+			obj, ok := change.Object.(*unstructured.Unstructured)
+			if !ok {
+				// the resource version is too old, start over from the current object
+				return ""
+			}
+			resourceVersion = obj.GetResourceVersion()
+			if change.Type == watch.Deleted {
+				w.view.SetTitle(fmt.Sprintf("get - (%s) deleted", w.name))
+				w.t.GetApplication().Draw()
+				continue
+			}
+			w.add(obj)
+			w.t.GetApplication().Draw()
+		}
+	}
+}
+
+// add records a version of the object, unless it was already seen, and shows it.
+func (w *objectWatch) add(obj *unstructured.Unstructured) {
+	w.lock.Lock()
+	for _, rev := range w.revisions {
+		if rev.resourceVersion == obj.GetResourceVersion() {
+			w.lock.Unlock()
+			return
+		}
+	}
+	w.revisions = append(w.revisions, newObjectRevision(obj))
+	w.lock.Unlock()
+
+	w.drawTimeline()
+	if w.t.GetApplication().GetFocus() != w.timeline {
+		w.showLive()
+	}
+}
+
+func (w *objectWatch) showLive() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	latest := w.revisions[len(w.revisions)-1]
+	w.view.SetTitle(fmt.Sprintf("get - (%s) live, %d revisions [Tab] timeline", w.name, len(w.revisions)))
+	w.view.SetText(tview.Escape(latest.manifest))
+}
+
+func (w *objectWatch) drawTimeline() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	row, _ := w.timeline.GetSelection()
+	w.timeline.Clear()
+	for col, name := range []string{"", "REVISION", "RESOURCE VERSION", "SEEN", "MANAGER", "OPERATION"} {
+		w.timeline.SetCell(0, col, tview.NewTableCell(name).SetSelectable(false).SetExpansion(1).
+			SetTextColor(tcell.ColorAntiqueWhite).SetAttributes(tcell.AttrBold))
+	}
+	for i, rev := range w.revisions {
+		mark := " "
+		if i == w.base {
+			mark = "*"
+		}
+		cells := []string{mark, fmt.Sprint(i + 1), rev.resourceVersion, rev.seen.Format("15:04:05"), rev.manager, rev.operation}
+		for col, value := range cells {
+			w.timeline.SetCell(i+1, col, tview.NewTableCell(value).SetExpansion(1).SetTextColor(tcell.ColorAntiqueWhite))
+		}
+	}
+	if row < 1 {
+		row = 1
+	}
+	w.timeline.Select(row, 0)
+}
+
+// diff shows the diff of a revision against the base marked, or against the revision before it.
+func (w *objectWatch) diff(i int) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if i < 0 || i >= len(w.revisions) {
+		return
+	}
+	from := i - 1
+	if w.base >= 0 && w.base != i {
+		from = w.base
+	}
+	if from < 0 {
+		w.view.SetTitle(fmt.Sprintf("get - (%s) revision 1 is the first one seen", w.name))
+		w.view.SetText(tview.Escape(w.revisions[i].manifest))
+		return
+	}
+	diff := unifiedDiff(w.revisions[from].manifest, w.revisions[i].manifest,
+		fmt.Sprintf("revision %d (%s)", from+1, w.revisions[from].resourceVersion),
+		fmt.Sprintf("revision %d (%s)", i+1, w.revisions[i].resourceVersion))
+	if diff == "" {
+		diff = "only the resource version or managed fields changed"
+	}
+	w.view.SetTitle(fmt.Sprintf("get - (%s) diff of revisions %d and %d [Tab] live", w.name, from+1, i+1))
+	w.view.SetText(colorDiff(diff))
+}
@@ -0,0 +1,45 @@
+package k8s
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNewObjectRevision(t *testing.T) {
+	tests := []struct {
+		name          string
+		object        string
+		wantManager   string
+		wantOperation string
+	}{
+		{
+			name:          "no managed fields",
+			object:        "{apiVersion: v1, kind: ConfigMap, metadata: {name: a, resourceVersion: '5'}}",
+			wantManager:   "-",
+			wantOperation: "-",
+		},
+		{
+			name: "latest writer",
+			object: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+  resourceVersion: '5'
+  managedFields:
+  - {manager: kubectl, operation: Update, time: '2020-01-02T00:00:00Z'}
+  - {manager: axe, operation: Apply, time: '2020-01-03T00:00:00Z'}
+  - {manager: controller, operation: Update, time: '2020-01-01T00:00:00Z'}
+`,
+			wantManager:   "axe",
+			wantOperation: "Apply",
+		},
+	}
+	for _, tt := range tests {
+		rev := newObjectRevision(&unstructured.Unstructured{Object: parseYAML(t, tt.object)})
+		if rev.resourceVersion != "5" || rev.manager != tt.wantManager || rev.operation != tt.wantOperation {
+			t.Errorf("%s: newObjectRevision() = %s %s %s, want 5 %s %s", tt.name, rev.resourceVersion, rev.manager, rev.operation, tt.wantManager, tt.wantOperation)
+		}
+	}
+}
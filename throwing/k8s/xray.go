@@ -0,0 +1,450 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/rancher/axe/throwing"
+	"github.com/rancher/axe/throwing/types"
+	"github.com/rivo/tview"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	xrayKind = "xray"
+)
+
+const (
+	healthy health = iota
+	progressing
+	failing
+)
+
+var (
+	healthColors = map[health]tcell.Color{
+		healthy:     tcell.ColorGreen,
+		progressing: tcell.ColorYellow,
+		failing:     tcell.ColorRed,
+	}
+
+	// xrayRoots are the kinds shown at the top of the tree, in this order, when they have no owner
+	xrayRoots = []string{"Ingress", "Service", "Deployment", "StatefulSet", "DaemonSet", "CronJob", "Job", "ReplicaSet", "Pod"}
+)
+
+type health int
+
+// xrayNode is an object of the namespace with the objects it leads to, through ownerReferences or well-known links.
+type xrayNode struct {
+	object   throwing.TreeObject
+	kind     string
+	uid      k8stypes.UID
+	owners   []metav1.OwnerReference
+	health   health
+	status   string
+	children []*xrayNode
+}
+
+type xrayGraph struct {
+	nodes  []*xrayNode
+	byUID  map[k8stypes.UID]*xrayNode
+	byName map[string]*xrayNode
+}
+
+func xray(t *throwing.TableView) {
+	namespace, name := getNamespaceAndName(t)
+	if t.GetResourceKind() == "namespaces" {
+		namespace = name
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	kind := types.ResourceKind{
+		Title: fmt.Sprintf("xray - %s", namespace),
+		Kind:  xrayKind,
+	}
+	tree := t.NewTreeView(kind, func() (*tview.TreeNode, error) {
+		return buildXray(t.GetClientSet(), namespace)
+	}, nil, xrayEventHandler)
+
+	page := fmt.Sprintf("%s@%s", xrayKind, namespace)
+	t.SetTableView(page, tree.Table())
+	t.SwitchPage(page, tree)
+}
+
+/*
+xrayEventHandler binds the actions on the object of a node of the tree. Marks, and with them the bulk actions, do not
+apply to a tree, nor do the views of the whole cluster.
+*/
+func xrayEventHandler(t *throwing.TableView) func(event *tcell.EventKey) *tcell.EventKey {
+	return func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() != tcell.KeyRune {
+			return event
+		}
+		switch event.Rune() {
+		case 'g':
+			get(t)
+		case 'D':
+			describe(t)
+		case 'e':
+			edit(t)
+		case 'd':
+			deleteSelected(t)
+		case 'l':
+			logs(t)
+		case 'x':
+			execute(t)
+		case 'a':
+			attach(t)
+		case 'U':
+			usedBy(t)
+		case 'W':
+			viewFieldOwners(t)
+		case 'S':
+			viewData(t)
+		case 's':
+			scale(t)
+		case 'R':
+			restart(t)
+		case 'H':
+			history(t)
+		case 'L':
+			editLabels(t)
+		case 'A':
+			editAnnotations(t)
+		case 'q':
+			t.RootPage()
+		}
+		return event
+	}
+}
+
+func buildXray(clientset *kubernetes.Clientset, namespace string) (*tview.TreeNode, error) {
+	g, err := newXrayGraph(clientset, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	// owned objects go below their owner, the others at the top
+	groups := map[string][]*xrayNode{}
+	for _, n := range g.nodes {
+		owned := false
+		for _, ref := range n.owners {
+			if owner, ok := g.byUID[ref.UID]; ok {
+				owner.children = append(owner.children, n)
+				owned = true
+			}
+		}
+		if !owned {
+			groups[n.kind] = append(groups[n.kind], n)
+		}
+	}
+
+	root := tview.NewTreeNode(fmt.Sprintf("Namespace/%s", namespace)).SetColor(tcell.ColorWhite)
+	for _, kind := range xrayRoots {
+		nodes := groups[kind]
+		if len(nodes) == 0 {
+			continue
+		}
+		plural := kind + "s"
+		if strings.HasSuffix(kind, "s") {
+			plural = kind + "es"
+		}
+		group := tview.NewTreeNode(fmt.Sprintf("%s (%d)", plural, len(nodes))).SetColor(tcell.ColorWhite)
+		for _, n := range nodes {
+			group.AddChild(n.treeNode(map[*xrayNode]bool{}))
+		}
+		root.AddChild(group)
+	}
+	return root, nil
+}
+
+// treeNode returns the tree below the node. An object is only followed once per branch, links can form cycles.
+func (n *xrayNode) treeNode(seen map[*xrayNode]bool) *tview.TreeNode {
+	text := fmt.Sprintf("%s/%s", n.kind, n.object.Name)
+	if n.status != "" {
+		text += "  " + n.status
+	}
+	node := tview.NewTreeNode(text).SetColor(healthColors[n.health])
+	if n.object.Kind != "" {
+		node.SetReference(n.object)
+	}
+	if seen[n] {
+		return node
+	}
+	seen[n] = true
+	for _, child := range n.children {
+		node.AddChild(child.treeNode(seen))
+	}
+	seen[n] = false
+	return node
+}
+
+func newXrayGraph(clientset *kubernetes.Clientset, namespace string) (*xrayGraph, error) {
+	g := &xrayGraph{
+		byUID:  map[k8stypes.UID]*xrayNode{},
+		byName: map[string]*xrayNode{},
+	}
+	listOptions := metav1.ListOptions{}
+
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deployments.Items {
+		n := g.add("Deployment", "deployments.apps", &d.ObjectMeta)
+		n.health, n.status = replicasHealth(d.Spec.Replicas, d.Status.ReadyReplicas)
+	}
+
+	replicaSets, err := clientset.AppsV1().ReplicaSets(namespace).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, rs := range replicaSets.Items {
+		n := g.add("ReplicaSet", "replicasets.apps", &rs.ObjectMeta)
+		n.health, n.status = replicasHealth(rs.Spec.Replicas, rs.Status.ReadyReplicas)
+	}
+
+	statefulSets, err := clientset.AppsV1().StatefulSets(namespace).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range statefulSets.Items {
+		n := g.add("StatefulSet", "statefulsets.apps", &s.ObjectMeta)
+		n.health, n.status = replicasHealth(s.Spec.Replicas, s.Status.ReadyReplicas)
+	}
+
+	daemonSets, err := clientset.AppsV1().DaemonSets(namespace).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, ds := range daemonSets.Items {
+		n := g.add("DaemonSet", "daemonsets.apps", &ds.ObjectMeta)
+		desired := ds.Status.DesiredNumberScheduled
+		n.health, n.status = replicasHealth(&desired, ds.Status.NumberReady)
+	}
+
+	jobs, err := clientset.BatchV1().Jobs(namespace).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, j := range jobs.Items {
+		n := g.add("Job", "jobs.batch", &j.ObjectMeta)
+		switch {
+		case j.Status.Failed > 0:
+			n.health, n.status = failing, fmt.Sprintf("%d failed", j.Status.Failed)
+		case j.Status.Active > 0:
+			n.health, n.status = progressing, fmt.Sprintf("%d active", j.Status.Active)
+		default:
+			n.status = fmt.Sprintf("%d succeeded", j.Status.Succeeded)
+		}
+	}
+
+	cronJobs, err := listCronJobs(clientset, namespace, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range cronJobs {
+		n := g.add("CronJob", c.tableKind, &c.ObjectMeta)
+		if c.suspended {
+			n.health, n.status = progressing, "suspended"
+		}
+	}
+
+	pods, err := clientset.CoreV1().Pods(namespace).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pods.Items {
+		n := g.add("Pod", "pods", &p.ObjectMeta)
+		n.health, n.status = podHealth(p)
+	}
+
+	configMaps, err := clientset.CoreV1().ConfigMaps(namespace).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range configMaps.Items {
+		g.add("ConfigMap", "configmaps", &c.ObjectMeta)
+	}
+
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range secrets.Items {
+		g.add("Secret", "secrets", &s.ObjectMeta)
+	}
+
+	claims, err := clientset.CoreV1().PersistentVolumeClaims(namespace).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range claims.Items {
+		n := g.add("PersistentVolumeClaim", "persistentvolumeclaims", &c.ObjectMeta)
+		n.status = string(c.Status.Phase)
+		switch c.Status.Phase {
+		case corev1.ClaimPending:
+			n.health = progressing
+		case corev1.ClaimLost:
+			n.health = failing
+		}
+	}
+
+	endpoints, err := clientset.CoreV1().Endpoints(namespace).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range endpoints.Items {
+		n := g.add("Endpoints", "endpoints", &e.ObjectMeta)
+		ready := 0
+		for _, subset := range e.Subsets {
+			ready += len(subset.Addresses)
+			for _, address := range append(subset.Addresses, subset.NotReadyAddresses...) {
+				if address.TargetRef != nil && address.TargetRef.Kind == "Pod" {
+					n.link(g.get("Pod", address.TargetRef.Name))
+				}
+			}
+		}
+		n.status = fmt.Sprintf("%d ready", ready)
+		if ready == 0 {
+			n.health = progressing
+		}
+	}
+
+	services, err := clientset.CoreV1().Services(namespace).List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range services.Items {
+		n := g.add("Service", "services", &s.ObjectMeta)
+		n.status = string(s.Spec.Type)
+		if s.Spec.Type == corev1.ServiceTypeExternalName {
+			continue
+		}
+		e := g.get("Endpoints", s.Name)
+		n.link(e)
+		n.health = e.health
+	}
+
+	ingresses, err := listIngresses(clientset, namespace, listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range ingresses {
+		n := g.add("Ingress", i.tableKind, &i.ObjectMeta)
+		for _, backend := range i.services {
+			s := g.get("Service", backend)
+			n.link(s)
+			if s.health > n.health {
+				n.health = s.health
+			}
+		}
+	}
+
+	// volumes are linked last, once every claim, config map and secret is known
+	for _, p := range pods.Items {
+		n := g.get("Pod", p.Name)
+		for _, v := range p.Spec.Volumes {
+			switch {
+			case v.ConfigMap != nil:
+				n.link(g.get("ConfigMap", v.ConfigMap.Name))
+			case v.Secret != nil:
+				n.link(g.get("Secret", v.Secret.SecretName))
+			case v.PersistentVolumeClaim != nil:
+				n.link(g.get("PersistentVolumeClaim", v.PersistentVolumeClaim.ClaimName))
+			case v.Projected != nil:
+				for _, source := range v.Projected.Sources {
+					if source.ConfigMap != nil {
+						n.link(g.get("ConfigMap", source.ConfigMap.Name))
+					}
+					if source.Secret != nil {
+						n.link(g.get("Secret", source.Secret.Name))
+					}
+				}
+			}
+		}
+	}
+	return g, nil
+}
+
+func (g *xrayGraph) add(kind, tableKind string, meta *metav1.ObjectMeta) *xrayNode {
+	n := &xrayNode{
+		object: throwing.TreeObject{
+			Kind:      tableKind,
+			Namespace: meta.Namespace,
+			Name:      meta.Name,
+		},
+		kind:   kind,
+		uid:    meta.UID,
+		owners: meta.OwnerReferences,
+	}
+	g.nodes = append(g.nodes, n)
+	g.byUID[n.uid] = n
+	g.byName[kind+"/"+meta.Name] = n
+	return n
+}
+
+// get returns the node of an object referenced by name. A reference to an object that does not exist gets a failing node without actions.
+func (g *xrayGraph) get(kind, name string) *xrayNode {
+	if n, ok := g.byName[kind+"/"+name]; ok {
+		return n
+	}
+	return &xrayNode{
+		object: throwing.TreeObject{Name: name},
+		kind:   kind,
+		health: failing,
+		status: "missing",
+	}
+}
+
+func (n *xrayNode) link(child *xrayNode) {
+	for _, c := range n.children {
+		if c == child {
+			return
+		}
+	}
+	n.children = append(n.children, child)
+}
+
+func replicasHealth(desired *int32, ready int32) (health, string) {
+	want := int32(1)
+	if desired != nil {
+		want = *desired
+	}
+	status := fmt.Sprintf("%d/%d ready", ready, want)
+	switch {
+	case ready >= want:
+		return healthy, status
+	case ready == 0:
+		return failing, status
+	}
+	return progressing, status
+}
+
+func podHealth(pod corev1.Pod) (health, string) {
+	for _, s := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		if w := s.State.Waiting; w != nil && w.Reason != "" && w.Reason != "ContainerCreating" && w.Reason != "PodInitializing" {
+			return failing, w.Reason
+		}
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodFailed:
+		return failing, string(pod.Status.Phase)
+	case corev1.PodSucceeded:
+		return healthy, string(pod.Status.Phase)
+	case corev1.PodRunning:
+		for _, c := range pod.Status.Conditions {
+			if c.Type == corev1.PodReady && c.Status != corev1.ConditionTrue {
+				return progressing, "Running, not ready"
+			}
+		}
+		return healthy, string(pod.Status.Phase)
+	}
+	return progressing, string(pod.Status.Phase)
+}
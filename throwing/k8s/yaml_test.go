@@ -0,0 +1,15 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/ghodss/yaml"
+)
+
+func parseYAML(t *testing.T, text string) map[string]interface{} {
+	obj := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(text), &obj); err != nil {
+		t.Fatal(err)
+	}
+	return obj
+}
@@ -0,0 +1,179 @@
+package throwing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/rancher/axe/throwing/datafeeder"
+)
+
+var (
+	// identityColumns are the columns that together identify the object of a row, a report such as the unused one lists
+	// objects of several resources that may share a namespace and a name
+	identityColumns = map[string]bool{
+		"NAMESPACE": true,
+		"NAME":      true,
+		"KIND":      true,
+		"RESOURCE":  true,
+	}
+)
+
+/*
+Rows are marked by their key rather than their index, so that marks survive refreshes that add, remove or reorder rows.
+The key is made of every identity column of the table, or of the first column in tables without any.
+*/
+
+func rowKey(header, row datafeeder.Row) string {
+	var parts []string
+	for col, name := range header {
+		if col < len(row) && identityColumns[name] {
+			parts = append(parts, row[col])
+		}
+	}
+	if len(parts) > 0 {
+		return strings.Join(parts, "/")
+	}
+	if len(row) > 0 {
+		return row[0]
+	}
+	return ""
+}
+
+func (t *TableView) cellsOf(row int) datafeeder.Row {
+	var cells datafeeder.Row
+	for col := 0; col < t.Table.GetColumnCount(); col++ {
+		cells = append(cells, t.Table.GetCell(row, col).Text)
+	}
+	return cells
+}
+
+func (t *TableView) keyOf(row int) string {
+	return rowKey(t.dataSource.Header(), t.cellsOf(row))
+}
+
+// pruneMarks forgets the marks of rows that are gone, it is called with the rows of every refresh.
+func (t *TableView) pruneMarks(header datafeeder.Row, data []datafeeder.Row) {
+	if len(t.marked) == 0 {
+		return
+	}
+	kept := map[string]bool{}
+	for _, row := range data {
+		if key := rowKey(header, row); t.marked[key] {
+			kept[key] = true
+		}
+	}
+	t.marked = kept
+}
+
+func (t *TableView) paintRow(row int, marked bool) {
+	color := tcell.ColorAntiqueWhite
+	if marked {
+		color = tcell.ColorYellow
+	}
+	for col := 0; col < t.Table.GetColumnCount(); col++ {
+		t.Table.GetCell(row, col).SetTextColor(color)
+	}
+}
+
+func (t *TableView) updateMarkTitle() {
+	if len(t.marked) == 0 {
+		t.Table.SetTitle(t.resourceKind.Title)
+		return
+	}
+	t.Table.SetTitle(fmt.Sprintf("%s [%d marked]", t.resourceKind.Title, len(t.marked)))
+}
+
+func (t *TableView) setMark(row int, marked bool) {
+	key := t.keyOf(row)
+	if key == "" {
+		return
+	}
+	if t.marked == nil {
+		t.marked = map[string]bool{}
+	}
+	if marked {
+		t.marked[key] = true
+	} else {
+		delete(t.marked, key)
+	}
+	t.paintRow(row, marked)
+}
+
+// ToggleMark marks the selected row, or unmarks it, and moves the selection to the next row.
+func (t *TableView) ToggleMark() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	row, column := t.Table.GetSelection()
+	if row < 1 {
+		return
+	}
+	t.setMark(row, !t.marked[t.keyOf(row)])
+	if row+1 < t.Table.GetRowCount() {
+		t.Table.Select(row+1, column)
+	}
+	t.updateMarkTitle()
+}
+
+// MarkAll marks every row shown, or unmarks them all if they are all marked already.
+func (t *TableView) MarkAll() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	all := true
+	for row := 1; row < t.Table.GetRowCount(); row++ {
+		if !t.marked[t.keyOf(row)] {
+			all = false
+		}
+	}
+	for row := 1; row < t.Table.GetRowCount(); row++ {
+		t.setMark(row, !all)
+	}
+	t.updateMarkTitle()
+}
+
+// MarkMatching marks the rows with a cell containing the filter, and returns how many rows it matched.
+func (t *TableView) MarkMatching(filter string) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	matched := 0
+	for row := 1; row < t.Table.GetRowCount(); row++ {
+		for _, cell := range t.cellsOf(row) {
+			if strings.Contains(cell, filter) {
+				t.setMark(row, true)
+				matched++
+				break
+			}
+		}
+	}
+	t.updateMarkTitle()
+	return matched
+}
+
+// ClearMarks unmarks every row.
+func (t *TableView) ClearMarks() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for row := 1; row < t.Table.GetRowCount(); row++ {
+		t.paintRow(row, false)
+	}
+	t.marked = nil
+	t.updateMarkTitle()
+}
+
+// MarkedRows returns the indexes in the table of the marked rows, in order.
+func (t *TableView) MarkedRows() []int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var rows []int
+	for row := 1; row < t.Table.GetRowCount(); row++ {
+		if t.marked[t.keyOf(row)] {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
@@ -0,0 +1,114 @@
+package throwing
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rancher/axe/throwing/datafeeder"
+	"github.com/rivo/tview"
+)
+
+func TestRowKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		header datafeeder.Row
+		row    datafeeder.Row
+		want   string
+	}{
+		{
+			name:   "namespaced",
+			header: datafeeder.Row{"NAMESPACE", "NAME", "READY", "AGE"},
+			row:    datafeeder.Row{"default", "web", "1/1", "3d"},
+			want:   "default/web",
+		},
+		{
+			name:   "cluster scoped",
+			header: datafeeder.Row{"NAME", "STATUS", "AGE"},
+			row:    datafeeder.Row{"node-1", "Ready", "3d"},
+			want:   "node-1",
+		},
+		{
+			name:   "several resources",
+			header: datafeeder.Row{"NAMESPACE", "NAME", "RESOURCE", "REASON"},
+			row:    datafeeder.Row{"default", "web", "configmaps", "not referenced"},
+			want:   "default/web/configmaps",
+		},
+		{
+			name:   "no identity column",
+			header: datafeeder.Row{"LOCAL", "REMOTE"},
+			row:    datafeeder.Row{"8080", "80"},
+			want:   "8080",
+		},
+		{
+			name:   "short row",
+			header: datafeeder.Row{"NAMESPACE", "NAME"},
+			row:    datafeeder.Row{"default"},
+			want:   "default",
+		},
+		{
+			name:   "empty row",
+			header: datafeeder.Row{"NAME"},
+		},
+	}
+	for _, tt := range tests {
+		if got := rowKey(tt.header, tt.row); got != tt.want {
+			t.Errorf("%s: rowKey() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+type staticSource struct {
+	header datafeeder.Row
+	data   []datafeeder.Row
+}
+
+func (s *staticSource) Data() []datafeeder.Row { return s.data }
+func (s *staticSource) Header() datafeeder.Row { return s.header }
+func (s *staticSource) Refresh() error         { return nil }
+
+func newMarkedTable(source *staticSource) *TableView {
+	t := &TableView{Table: tview.NewTable(), dataSource: source}
+	for col, name := range source.header {
+		t.addHeaderCell(col, name)
+	}
+	for r, row := range source.data {
+		for col, value := range row {
+			t.addBodyCell(r, col, value)
+		}
+	}
+	return t
+}
+
+func TestMarks(t *testing.T) {
+	source := &staticSource{
+		header: datafeeder.Row{"NAMESPACE", "NAME", "RESOURCE", "REASON"},
+		data: []datafeeder.Row{
+			{"default", "web", "configmaps", "not referenced"},
+			{"default", "web", "secrets", "not referenced"},
+			{"default", "web", "persistentvolumeclaims", "not mounted"},
+		},
+	}
+	table := newMarkedTable(source)
+
+	table.Table.Select(2, 0)
+	table.ToggleMark()
+	if got, want := table.MarkedRows(), []int{2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MarkedRows() = %v, want %v", got, want)
+	}
+
+	table.MarkAll()
+	if got, want := table.MarkedRows(), []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MarkedRows() after MarkAll() = %v, want %v", got, want)
+	}
+
+	table.pruneMarks(source.header, source.data[:1])
+	if got, want := table.MarkedRows(), []int{1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MarkedRows() after pruneMarks() = %v, want %v", got, want)
+	}
+
+	table.MarkAll()
+	table.MarkAll()
+	if got := table.MarkedRows(); len(got) != 0 {
+		t.Errorf("MarkedRows() after MarkAll() twice = %v, want none", got)
+	}
+}
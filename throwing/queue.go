@@ -15,6 +15,11 @@ type PrimitiveQueue struct {
 }
 
 func (p *PrimitiveQueue) Enqueue(t PageTrack) {
+	// switching to the page that is already shown must not add a step to walk back through
+	if !p.Empty() && p.items[len(p.items)-1].Primitive == t.Primitive {
+		p.items[len(p.items)-1] = t
+		return
+	}
 	p.items = append(p.items, t)
 }
 
@@ -0,0 +1,206 @@
+package throwing
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gdamore/tcell"
+	"github.com/rivo/tview"
+)
+
+// TerminalSession is a terminal opened as a tab. Sessions keep running while the user is on other pages.
+type TerminalSession struct {
+	Title  string
+	view   *TerminalView
+	table  *TableView
+	tabs   *terminalTabs
+	exited bool
+}
+
+/*
+terminalTabs shows every open terminal session as a tab.
+
+Ctrl-]: go back to the table, sessions keep running
+Alt-Left/Alt-Right/Alt-1..9: switch between tabs
+Enter: close a session that has exited
+*/
+type terminalTabs struct {
+	*tview.Flex
+	app      *AppView
+	bar      *tview.TextView
+	pages    *tview.Pages
+	sessions []*TerminalSession
+	current  int
+	lock     sync.Mutex
+}
+
+func newTerminalTabs(app *AppView) *terminalTabs {
+	tabs := &terminalTabs{
+		Flex:  tview.NewFlex(),
+		app:   app,
+		bar:   tview.NewTextView(),
+		pages: tview.NewPages(),
+	}
+	tabs.bar.SetDynamicColors(true).SetRegions(true).SetWrap(false).SetBackgroundColor(tcell.ColorGray)
+	tabs.Flex.SetDirection(tview.FlexRow)
+	tabs.Flex.AddItem(tabs.bar, 1, 1, false)
+	tabs.Flex.AddItem(tabs.pages, 0, 1, true)
+	tabs.Flex.SetInputCapture(tabs.inputHandler)
+	return tabs
+}
+
+func (tabs *terminalTabs) inputHandler(event *tcell.EventKey) *tcell.EventKey {
+	tabs.lock.Lock()
+	defer tabs.lock.Unlock()
+
+	switch {
+	case event.Key() == tcell.KeyCtrlRightSq:
+		tabs.app.SwitchToRootPage()
+		return nil
+	case event.Modifiers()&tcell.ModAlt != 0 && event.Key() == tcell.KeyLeft:
+		tabs.switchTo(tabs.current - 1)
+		return nil
+	case event.Modifiers()&tcell.ModAlt != 0 && event.Key() == tcell.KeyRight:
+		tabs.switchTo(tabs.current + 1)
+		return nil
+	case event.Modifiers()&tcell.ModAlt != 0 && event.Key() == tcell.KeyRune && event.Rune() >= '1' && event.Rune() <= '9':
+		tabs.switchTo(int(event.Rune() - '1'))
+		return nil
+	case event.Key() == tcell.KeyEnter && len(tabs.sessions) > 0 && tabs.sessions[tabs.current].exited:
+		if !tabs.remove(tabs.sessions[tabs.current]) {
+			tabs.app.SwitchToRootPage()
+		}
+		return nil
+	}
+	return event
+}
+
+func (tabs *terminalTabs) add(session *TerminalSession) {
+	tabs.lock.Lock()
+	defer tabs.lock.Unlock()
+
+	tabs.sessions = append(tabs.sessions, session)
+	tabs.pages.AddPage(tabs.pageName(session), session.view, true, false)
+	tabs.switchTo(len(tabs.sessions) - 1)
+}
+
+// remove closes the tab of session and reports whether any tab is left. The caller must hold the lock.
+func (tabs *terminalTabs) remove(session *TerminalSession) bool {
+	for i, s := range tabs.sessions {
+		if s != session {
+			continue
+		}
+		tabs.sessions = append(tabs.sessions[:i], tabs.sessions[i+1:]...)
+		tabs.pages.RemovePage(tabs.pageName(session))
+		session.view.Close()
+		break
+	}
+	if len(tabs.sessions) == 0 {
+		tabs.current = 0
+		return false
+	}
+	tabs.switchTo(tabs.current)
+	return true
+}
+
+// switchTo shows the tab at index, clamped to the open sessions. The caller must hold the lock.
+func (tabs *terminalTabs) switchTo(index int) {
+	if len(tabs.sessions) == 0 {
+		return
+	}
+	if index < 0 {
+		index = len(tabs.sessions) - 1
+	} else if index >= len(tabs.sessions) {
+		index = 0
+	}
+	tabs.current = index
+	tabs.pages.SwitchToPage(tabs.pageName(tabs.sessions[index]))
+	tabs.drawBar()
+	tabs.app.SetFocus(tabs.sessions[index].view)
+}
+
+func (tabs *terminalTabs) drawBar() {
+	tabs.bar.Clear()
+	for i, s := range tabs.sessions {
+		title := s.Title
+		if s.exited {
+			title += " (exited)"
+		}
+		color := "black"
+		if i == tabs.current {
+			color = "blue"
+		}
+		fmt.Fprintf(tabs.bar, `%d [%s]%s[white] `, i+1, color, tview.Escape(title))
+	}
+	fmt.Fprintf(tabs.bar, ` [black]Ctrl-] back  Alt-←/→ switch[white]`)
+}
+
+func (tabs *terminalTabs) pageName(session *TerminalSession) string {
+	return fmt.Sprintf("%p", session)
+}
+
+func (tabs *terminalTabs) isCurrent(session *TerminalSession) bool {
+	return len(tabs.sessions) > 0 && tabs.sessions[tabs.current] == session && tabs.app.GetFocus() == session.view
+}
+
+/*
+OpenTerminal opens view as a new tab on the terminal page and switches to it.
+The caller streams the remote process into the view and calls Finish once it ends.
+*/
+func (t *TableView) OpenTerminal(title string, view *TerminalView) *TerminalSession {
+	app := t.app
+	if app.terminals == nil {
+		app.terminals = newTerminalTabs(app)
+	}
+	session := &TerminalSession{
+		Title: title,
+		view:  view,
+		table: t,
+		tabs:  app.terminals,
+	}
+	view.SetBorder(true)
+	view.SetChangedFunc(func() {
+		app.Application.Draw()
+	})
+	app.SwitchPage(app.currentPage, app.terminals, nil)
+	app.terminals.add(session)
+	return session
+}
+
+// ShowTerminals switches to the terminal page if any session is open.
+func (t *TableView) ShowTerminals() {
+	app := t.app
+	if app.terminals == nil {
+		t.UpdateStatus("no terminal sessions", false)
+		return
+	}
+
+	app.terminals.lock.Lock()
+	defer app.terminals.lock.Unlock()
+	if len(app.terminals.sessions) == 0 {
+		t.UpdateStatus("no terminal sessions", false)
+		return
+	}
+	app.SwitchPage(app.currentPage, app.terminals, nil)
+	app.terminals.switchTo(app.terminals.current)
+}
+
+/*
+Finish marks the session as exited. If the user is looking at it, the tab is closed and the status is shown over the
+table, otherwise the status is printed in the terminal and the tab stays open until it is closed with Enter.
+*/
+func (s *TerminalSession) Finish(status string, isError bool) {
+	s.tabs.app.Application.QueueUpdateDraw(func() {
+		s.tabs.lock.Lock()
+		defer s.tabs.lock.Unlock()
+
+		s.exited = true
+		if s.tabs.isCurrent(s) {
+			s.tabs.remove(s)
+			s.table.UpdateStatus(status, isError)
+			return
+		}
+		fmt.Fprintf(s.view, "\r\n[%s, press Enter to close]", status)
+		s.tabs.drawBar()
+	})
+}
@@ -32,6 +32,15 @@ type TableView struct {
 	actions      []types.Action
 	resourceKind types.ResourceKind
 	search       string
+	// front is shown in place of the table when set, the table then only holds the selection for the actions
+	front tview.Primitive
+	// marked holds the keys of the marked rows, see rowKey
+	marked map[string]bool
+	// refreshInterval and refreshChanged are set by RefreshEvery
+	refreshInterval time.Duration
+	refreshChanged  func() bool
+	// refreshStop is closed once the table refreshing every interval is not shown anymore
+	refreshStop <-chan struct{}
 }
 
 type EventHandler func(t *TableView) func(event *tcell.EventKey) *tcell.EventKey
@@ -130,6 +139,14 @@ func (t *TableView) run(ctx context.Context) {
 	}
 }
 
+// view returns the primitive shown for the table.
+func (t *TableView) view() tview.Primitive {
+	if t.front != nil {
+		return t.front
+	}
+	return t
+}
+
 func (t *TableView) GetSelectionName() string {
 	row, _ := t.Table.GetSelection()
 	cell := t.Table.GetCell(row, 0)
@@ -157,6 +174,8 @@ func (t *TableView) draw() {
 
 	header := t.dataSource.Header()
 	data := t.dataSource.Data()
+	wasMarked := len(t.marked) > 0
+	t.pruneMarks(header, data)
 
 	nameRow := 0
 	for col, name := range header {
@@ -177,11 +196,17 @@ func (t *TableView) draw() {
 		for col, value := range row {
 			t.addBodyCell(r, col, value)
 		}
+		if t.marked[rowKey(header, row)] {
+			t.paintRow(r+1, true)
+		}
 		r++
 	}
 	if t.search != "" {
 		t.search = ""
 	}
+	if wasMarked {
+		t.updateMarkTitle()
+	}
 	t.GetApplication().Draw()
 }
 
@@ -208,7 +233,7 @@ func (t *TableView) InsertDialog(name string, page tview.Primitive, dialog tview
 	newpage := tview.NewPages()
 	newpage.AddPage(name, page, true, true).
 		AddPage("dialog", center(dialog, 50, 20), true, true)
-	t.app.SwitchPage(t.app.currentPage, newpage, t.actions)
+	t.app.showDialog(newpage, t.actions)
 	t.app.Application.SetFocus(dialog)
 }
 
@@ -232,10 +257,10 @@ func (t *TableView) UpdateStatus(status string, isError bool) tview.Primitive {
 	statusBar.SetTextAlign(tview.AlignCenter)
 	newpage := tview.NewPages()
 	if _, ok := t.app.tableViews[t.app.currentPage]; ok {
-		newpage.AddPage("handler", t.app.currentPrimitive, true, true)
+		newpage.AddPage("handler", t.app.currentPrimitive.view(), true, true)
 	}
 	newpage.AddPage("dialog", center(statusBar, 100, 5), true, true)
-	t.app.SwitchPage(t.app.currentPage, newpage, t.actions)
+	t.app.showDialog(newpage, t.actions)
 
 	go func() {
 		time.Sleep(time.Second * errorDelayTime)
@@ -281,6 +306,51 @@ func (t *TableView) SwitchPage(page string, draw tview.Primitive) {
 	t.app.SwitchPage(page, draw, t.app.tableViews[page].actions)
 }
 
+/*
+WhileShown returns a channel that is closed once another page than p is shown, for the work a page does while it is
+shown, e.g. watches. Dialogs shown over p do not count as leaving it. The channel is closed already if p is not shown.
+*/
+func (t *TableView) WhileShown(p tview.Primitive) <-chan struct{} {
+	return t.app.whileShown(p)
+}
+
+/*
+RefreshEvery refreshes the table every interval while it is shown, e.g. to keep counters live. The refreshes stop when
+another page is shown and start again when the table is. If changed is not nil, the table is only refreshed when it
+returns true.
+*/
+func (t *TableView) RefreshEvery(interval time.Duration, changed func() bool) {
+	t.refreshInterval, t.refreshChanged = interval, changed
+	t.refreshWhileShown()
+}
+
+func (t *TableView) refreshWhileShown() {
+	if t.refreshStop != nil {
+		select {
+		case <-t.refreshStop:
+		default:
+			// refreshing already
+			return
+		}
+	}
+	stop := t.WhileShown(t.view())
+	t.refreshStop = stop
+	go func() {
+		ticker := time.NewTicker(t.refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if t.refreshChanged == nil || t.refreshChanged() {
+					t.RefreshManual()
+				}
+			}
+		}
+	}()
+}
+
 func (t *TableView) SetCurrentPage(page string) {
 	t.app.currentPage = page
 }
@@ -0,0 +1,713 @@
+package throwing
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell"
+	"github.com/rivo/tview"
+)
+
+const (
+	stateGround = iota
+	stateEscape
+	stateCharset
+	stateCSI
+	stateOSC
+	stateOSCEscape
+)
+
+type termCell struct {
+	r     rune
+	style tcell.Style
+}
+
+/*
+TerminalView is a primitive that emulates a VT100/xterm compatible terminal. Output of a remote process is written to
+it through Write, and keys pressed while it has focus are encoded and written to the input writer. The emulator keeps a
+single screen (plus the alternate screen used by full screen programs) and has no scrollback.
+*/
+type TerminalView struct {
+	*tview.Box
+	lock sync.Mutex
+
+	// pending are the keys not written to the input yet and resizing tells that the resized handler has not seen the
+	// current size yet, the writer is woken up when either is set
+	pending  [][]byte
+	resizing bool
+	wake     chan struct{}
+	closed   bool
+	resized  func(width, height int)
+	changed  func()
+
+	cells      [][]termCell
+	savedCells [][]termCell
+	width      int
+	height     int
+	cursorX    int
+	cursorY    int
+	savedX     int
+	savedY     int
+	style      tcell.Style
+	savedStyle tcell.Style
+	top        int
+	bottom     int
+	wrapNext   bool
+	showCursor bool
+	altScreen  bool
+
+	state   int
+	params  []byte
+	partial []byte
+}
+
+func NewTerminalView(input io.Writer) *TerminalView {
+	t := &TerminalView{
+		Box:        tview.NewBox(),
+		wake:       make(chan struct{}, 1),
+		style:      tcell.StyleDefault,
+		showCursor: true,
+	}
+	t.resize(80, 24)
+
+	// keys are forwarded by a single goroutine so that they keep their order without blocking the event loop, the queue
+	// has no bound so that a slow writer delays keys but never loses them. The same goroutine reports sizes, only the
+	// latest one, so that quick resizes cannot reach the handler out of order.
+	go func() {
+		for range t.wake {
+			t.lock.Lock()
+			pending, resizing, resized := t.pending, t.resizing, t.resized
+			width, height := t.width, t.height
+			t.pending, t.resizing = nil, false
+			t.lock.Unlock()
+			for _, b := range pending {
+				if _, err := input.Write(b); err != nil {
+					t.Close()
+					return
+				}
+			}
+			if resizing && resized != nil {
+				resized(width, height)
+			}
+		}
+	}()
+	return t
+}
+
+// Close stops forwarding keys to the input writer.
+func (t *TerminalView) Close() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !t.closed {
+		t.closed = true
+		t.pending = nil
+		close(t.wake)
+	}
+}
+
+// SetResizedFunc sets a handler called with the new inner size whenever the view is drawn at a different size.
+func (t *TerminalView) SetResizedFunc(handler func(width, height int)) *TerminalView {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.resized = handler
+	return t
+}
+
+// SetChangedFunc sets a handler called after new output was written.
+func (t *TerminalView) SetChangedFunc(handler func()) *TerminalView {
+	t.changed = handler
+	return t
+}
+
+func (t *TerminalView) Size() (int, int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.width, t.height
+}
+
+func (t *TerminalView) Write(p []byte) (int, error) {
+	t.lock.Lock()
+	data := append(t.partial, p...)
+	t.partial = nil
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size == 1 && !utf8.FullRune(data) {
+			t.partial = append([]byte(nil), data...)
+			break
+		}
+		data = data[size:]
+		t.feed(r)
+	}
+	t.lock.Unlock()
+
+	if t.changed != nil {
+		t.changed()
+	}
+	return len(p), nil
+}
+
+func (t *TerminalView) feed(r rune) {
+	switch t.state {
+	case stateGround:
+		t.ground(r)
+	case stateEscape:
+		t.escape(r)
+	case stateCharset:
+		t.state = stateGround
+	case stateCSI:
+		switch {
+		case r >= 0x30 && r <= 0x3f:
+			t.params = append(t.params, byte(r))
+		case r >= 0x20 && r <= 0x2f:
+			// intermediate bytes are not used by any sequence we handle
+		case r >= 0x40 && r <= 0x7e:
+			t.csi(r)
+			t.state = stateGround
+		default:
+			t.ground(r)
+		}
+	case stateOSC:
+		// window titles and the like are ignored, only the terminator matters
+		switch r {
+		case 0x07:
+			t.state = stateGround
+		case 0x1b:
+			t.state = stateOSCEscape
+		}
+	case stateOSCEscape:
+		t.state = stateGround
+	}
+}
+
+func (t *TerminalView) ground(r rune) {
+	switch r {
+	case 0x07:
+	case 0x08:
+		if t.cursorX > 0 {
+			t.cursorX--
+		}
+		t.wrapNext = false
+	case 0x09:
+		t.cursorX = minInt((t.cursorX/8+1)*8, t.width-1)
+	case 0x0a, 0x0b, 0x0c:
+		t.lineFeed()
+	case 0x0d:
+		t.cursorX = 0
+		t.wrapNext = false
+	case 0x1b:
+		t.state = stateEscape
+	default:
+		if r < 0x20 || r == 0x7f {
+			return
+		}
+		t.put(r)
+	}
+}
+
+func (t *TerminalView) escape(r rune) {
+	t.state = stateGround
+	switch r {
+	case '[':
+		t.params = t.params[:0]
+		t.state = stateCSI
+	case ']':
+		t.state = stateOSC
+	case '(', ')', '*', '+':
+		t.state = stateCharset
+	case '7':
+		t.saveCursor()
+	case '8':
+		t.restoreCursor()
+	case 'D':
+		t.lineFeed()
+	case 'E':
+		t.cursorX = 0
+		t.lineFeed()
+	case 'M':
+		if t.cursorY == t.top {
+			t.scrollDown(1)
+		} else if t.cursorY > 0 {
+			t.cursorY--
+		}
+	case 'c':
+		t.reset()
+	}
+}
+
+func (t *TerminalView) csi(final rune) {
+	raw := string(t.params)
+	private := strings.HasPrefix(raw, "?")
+	params := parseParams(strings.TrimLeft(raw, "?>=<"))
+	n := param(params, 0, 1)
+
+	switch final {
+	case '@':
+		t.insertBlanks(n)
+	case 'A':
+		t.moveCursor(t.cursorX, t.cursorY-n)
+	case 'B', 'e':
+		t.moveCursor(t.cursorX, t.cursorY+n)
+	case 'C', 'a':
+		t.moveCursor(t.cursorX+n, t.cursorY)
+	case 'D':
+		t.moveCursor(t.cursorX-n, t.cursorY)
+	case 'E':
+		t.moveCursor(0, t.cursorY+n)
+	case 'F':
+		t.moveCursor(0, t.cursorY-n)
+	case 'G', '`':
+		t.moveCursor(n-1, t.cursorY)
+	case 'H', 'f':
+		t.moveCursor(param(params, 1, 1)-1, n-1)
+	case 'd':
+		t.moveCursor(t.cursorX, n-1)
+	case 'J':
+		t.eraseDisplay(param(params, 0, 0))
+	case 'K':
+		t.eraseLine(param(params, 0, 0))
+	case 'L':
+		if t.cursorY >= t.top && t.cursorY <= t.bottom {
+			t.scrollRegion(t.cursorY, t.bottom, -n)
+		}
+	case 'M':
+		if t.cursorY >= t.top && t.cursorY <= t.bottom {
+			t.scrollRegion(t.cursorY, t.bottom, n)
+		}
+	case 'P':
+		t.deleteChars(n)
+	case 'S':
+		t.scrollUp(n)
+	case 'T':
+		t.scrollDown(n)
+	case 'X':
+		for x := t.cursorX; x < t.cursorX+n && x < t.width; x++ {
+			t.cells[t.cursorY][x] = t.blank()
+		}
+	case 'm':
+		t.sgr(params)
+	case 'r':
+		top, bottom := param(params, 0, 1)-1, param(params, 1, t.height)-1
+		if top < bottom && bottom < t.height {
+			t.top, t.bottom = top, bottom
+			t.moveCursor(0, 0)
+		}
+	case 's':
+		t.saveCursor()
+	case 'u':
+		t.restoreCursor()
+	case 'h', 'l':
+		if private {
+			t.setMode(params, final == 'h')
+		}
+	case 'n':
+		switch n {
+		case 5:
+			t.reply("\x1b[0n")
+		case 6:
+			t.reply(fmt.Sprintf("\x1b[%d;%dR", t.cursorY+1, t.cursorX+1))
+		}
+	case 'c':
+		if !private {
+			t.reply("\x1b[?1;2c")
+		}
+	}
+}
+
+func (t *TerminalView) setMode(params []int, on bool) {
+	for _, p := range params {
+		switch p {
+		case 25:
+			t.showCursor = on
+		case 47, 1047, 1049:
+			if on == t.altScreen {
+				continue
+			}
+			if on {
+				if p == 1049 {
+					t.saveCursor()
+				}
+				t.savedCells, t.cells = t.cells, newCells(t.width, t.height, t.blank())
+			} else {
+				t.cells, t.savedCells = t.savedCells, nil
+				if p == 1049 {
+					t.restoreCursor()
+				}
+			}
+			t.altScreen = on
+		}
+	}
+}
+
+func (t *TerminalView) sgr(params []int) {
+	if len(params) == 0 {
+		params = []int{0}
+	}
+	for i := 0; i < len(params); i++ {
+		p := params[i]
+		switch {
+		case p == 0:
+			t.style = tcell.StyleDefault
+		case p == 1:
+			t.style = t.style.Bold(true)
+		case p == 2:
+			t.style = t.style.Dim(true)
+		case p == 4:
+			t.style = t.style.Underline(true)
+		case p == 5:
+			t.style = t.style.Blink(true)
+		case p == 7:
+			t.style = t.style.Reverse(true)
+		case p == 22:
+			t.style = t.style.Bold(false).Dim(false)
+		case p == 24:
+			t.style = t.style.Underline(false)
+		case p == 25:
+			t.style = t.style.Blink(false)
+		case p == 27:
+			t.style = t.style.Reverse(false)
+		case p >= 30 && p <= 37:
+			t.style = t.style.Foreground(tcell.Color(p - 30))
+		case p >= 90 && p <= 97:
+			t.style = t.style.Foreground(tcell.Color(p - 90 + 8))
+		case p == 39:
+			t.style = t.style.Foreground(tcell.ColorDefault)
+		case p >= 40 && p <= 47:
+			t.style = t.style.Background(tcell.Color(p - 40))
+		case p >= 100 && p <= 107:
+			t.style = t.style.Background(tcell.Color(p - 100 + 8))
+		case p == 49:
+			t.style = t.style.Background(tcell.ColorDefault)
+		case p == 38 || p == 48:
+			color, consumed := extendedColor(params[i+1:])
+			i += consumed
+			if consumed == 0 {
+				continue
+			}
+			if p == 38 {
+				t.style = t.style.Foreground(color)
+			} else {
+				t.style = t.style.Background(color)
+			}
+		}
+	}
+}
+
+// extendedColor parses the arguments of a 38/48 SGR parameter, either `5;n` or `2;r;g;b`.
+func extendedColor(params []int) (tcell.Color, int) {
+	if len(params) >= 2 && params[0] == 5 {
+		return tcell.Color(params[1] & 0xff), 2
+	}
+	if len(params) >= 4 && params[0] == 2 {
+		return tcell.NewRGBColor(int32(params[1]), int32(params[2]), int32(params[3])), 4
+	}
+	return tcell.ColorDefault, 0
+}
+
+func (t *TerminalView) put(r rune) {
+	if t.wrapNext {
+		t.cursorX = 0
+		t.lineFeed()
+	}
+	t.cells[t.cursorY][t.cursorX] = termCell{r: r, style: t.style}
+	if t.cursorX == t.width-1 {
+		t.wrapNext = true
+	} else {
+		t.cursorX++
+	}
+}
+
+func (t *TerminalView) lineFeed() {
+	t.wrapNext = false
+	if t.cursorY == t.bottom {
+		t.scrollUp(1)
+	} else if t.cursorY < t.height-1 {
+		t.cursorY++
+	}
+}
+
+func (t *TerminalView) scrollUp(n int) {
+	t.scrollRegion(t.top, t.bottom, n)
+}
+
+func (t *TerminalView) scrollDown(n int) {
+	t.scrollRegion(t.top, t.bottom, -n)
+}
+
+// scrollRegion moves the lines between top and bottom up by n lines, or down when n is negative.
+func (t *TerminalView) scrollRegion(top, bottom, n int) {
+	lines := bottom - top + 1
+	if n > lines {
+		n = lines
+	} else if n < -lines {
+		n = -lines
+	}
+	if n > 0 {
+		copy(t.cells[top:bottom+1], t.cells[top+n:bottom+1])
+		for y := bottom - n + 1; y <= bottom; y++ {
+			t.cells[y] = newLine(t.width, t.blank())
+		}
+	} else if n < 0 {
+		n = -n
+		copy(t.cells[top+n:bottom+1], t.cells[top:bottom+1-n])
+		for y := top; y < top+n; y++ {
+			t.cells[y] = newLine(t.width, t.blank())
+		}
+	}
+}
+
+func (t *TerminalView) moveCursor(x, y int) {
+	t.cursorX = maxInt(0, minInt(x, t.width-1))
+	t.cursorY = maxInt(0, minInt(y, t.height-1))
+	t.wrapNext = false
+}
+
+func (t *TerminalView) saveCursor() {
+	t.savedX, t.savedY, t.savedStyle = t.cursorX, t.cursorY, t.style
+}
+
+func (t *TerminalView) restoreCursor() {
+	t.style = t.savedStyle
+	t.moveCursor(t.savedX, t.savedY)
+}
+
+func (t *TerminalView) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		t.eraseLine(0)
+		for y := t.cursorY + 1; y < t.height; y++ {
+			t.cells[y] = newLine(t.width, t.blank())
+		}
+	case 1:
+		t.eraseLine(1)
+		for y := 0; y < t.cursorY; y++ {
+			t.cells[y] = newLine(t.width, t.blank())
+		}
+	case 2, 3:
+		t.cells = newCells(t.width, t.height, t.blank())
+	}
+}
+
+func (t *TerminalView) eraseLine(mode int) {
+	from, to := 0, t.width
+	switch mode {
+	case 0:
+		from = t.cursorX
+	case 1:
+		to = t.cursorX + 1
+	}
+	for x := from; x < to && x < t.width; x++ {
+		t.cells[t.cursorY][x] = t.blank()
+	}
+}
+
+func (t *TerminalView) insertBlanks(n int) {
+	line := t.cells[t.cursorY]
+	n = minInt(n, t.width-t.cursorX)
+	copy(line[t.cursorX+n:], line[t.cursorX:])
+	for x := t.cursorX; x < t.cursorX+n; x++ {
+		line[x] = t.blank()
+	}
+}
+
+func (t *TerminalView) deleteChars(n int) {
+	line := t.cells[t.cursorY]
+	n = minInt(n, t.width-t.cursorX)
+	copy(line[t.cursorX:], line[t.cursorX+n:])
+	for x := t.width - n; x < t.width; x++ {
+		line[x] = t.blank()
+	}
+}
+
+// blank is an empty cell carrying the current background, as erase operations do on a real terminal.
+func (t *TerminalView) blank() termCell {
+	_, bg, _ := t.style.Decompose()
+	return termCell{r: ' ', style: tcell.StyleDefault.Background(bg)}
+}
+
+func (t *TerminalView) reset() {
+	t.style = tcell.StyleDefault
+	t.showCursor = true
+	t.altScreen = false
+	t.savedCells = nil
+	t.cells = newCells(t.width, t.height, t.blank())
+	t.top, t.bottom = 0, t.height-1
+	t.moveCursor(0, 0)
+}
+
+func (t *TerminalView) resize(width, height int) {
+	// keep the bottom of the old screen, which is where the cursor usually is
+	offset := maxInt(0, t.cursorY-height+1)
+	t.cells = resizeCells(t.cells, width, height, offset)
+	if t.savedCells != nil {
+		t.savedCells = resizeCells(t.savedCells, width, height, offset)
+	}
+	t.width, t.height = width, height
+	t.top, t.bottom = 0, height-1
+	t.moveCursor(t.cursorX, t.cursorY-offset)
+}
+
+// send queues bytes for the input writer. The caller must hold the lock.
+func (t *TerminalView) send(b []byte) {
+	if t.closed {
+		return
+	}
+	t.pending = append(t.pending, b)
+	t.wakeWriter()
+}
+
+// wakeWriter wakes up the goroutine forwarding keys and sizes. The caller must hold the lock.
+func (t *TerminalView) wakeWriter() {
+	select {
+	case t.wake <- struct{}{}:
+	default:
+		// the writer is awake already and will find what was queued
+	}
+}
+
+func (t *TerminalView) reply(s string) {
+	t.send([]byte(s))
+}
+
+func (t *TerminalView) Draw(screen tcell.Screen) {
+	t.Box.Draw(screen)
+	x, y, width, height := t.GetInnerRect()
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	t.lock.Lock()
+	if width != t.width || height != t.height {
+		t.resize(width, height)
+		if !t.closed {
+			t.resizing = true
+			t.wakeWriter()
+		}
+	}
+	for row := 0; row < t.height; row++ {
+		for col := 0; col < t.width; col++ {
+			c := t.cells[row][col]
+			screen.SetContent(x+col, y+row, c.r, nil, c.style)
+		}
+	}
+	if t.HasFocus() && t.showCursor {
+		screen.ShowCursor(x+t.cursorX, y+t.cursorY)
+	}
+	t.lock.Unlock()
+}
+
+func (t *TerminalView) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return t.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		if b := encodeKey(event); len(b) > 0 {
+			t.lock.Lock()
+			t.send(b)
+			t.lock.Unlock()
+		}
+	})
+}
+
+var keySequences = map[tcell.Key]string{
+	tcell.KeyUp:         "\x1b[A",
+	tcell.KeyDown:       "\x1b[B",
+	tcell.KeyRight:      "\x1b[C",
+	tcell.KeyLeft:       "\x1b[D",
+	tcell.KeyHome:       "\x1b[H",
+	tcell.KeyEnd:        "\x1b[F",
+	tcell.KeyInsert:     "\x1b[2~",
+	tcell.KeyDelete:     "\x1b[3~",
+	tcell.KeyPgUp:       "\x1b[5~",
+	tcell.KeyPgDn:       "\x1b[6~",
+	tcell.KeyBacktab:    "\x1b[Z",
+	tcell.KeyF1:         "\x1bOP",
+	tcell.KeyF2:         "\x1bOQ",
+	tcell.KeyF3:         "\x1bOR",
+	tcell.KeyF4:         "\x1bOS",
+	tcell.KeyF5:         "\x1b[15~",
+	tcell.KeyF6:         "\x1b[17~",
+	tcell.KeyF7:         "\x1b[18~",
+	tcell.KeyF8:         "\x1b[19~",
+	tcell.KeyF9:         "\x1b[20~",
+	tcell.KeyF10:        "\x1b[21~",
+	tcell.KeyF11:        "\x1b[23~",
+	tcell.KeyF12:        "\x1b[24~",
+	tcell.KeyBackspace2: "\x7f",
+}
+
+// encodeKey translates a key event into the bytes an xterm would send for it.
+func encodeKey(event *tcell.EventKey) []byte {
+	var prefix string
+	if event.Modifiers()&tcell.ModAlt != 0 {
+		prefix = "\x1b"
+	}
+	if event.Key() == tcell.KeyRune {
+		return []byte(prefix + string(event.Rune()))
+	}
+	if s, ok := keySequences[event.Key()]; ok {
+		return []byte(prefix + s)
+	}
+	if event.Key() < 0x20 {
+		return []byte(prefix + string(rune(event.Key())))
+	}
+	return nil
+}
+
+func parseParams(s string) []int {
+	if s == "" {
+		return nil
+	}
+	var params []int
+	for _, p := range strings.Split(strings.Replace(s, ":", ";", -1), ";") {
+		n, _ := strconv.Atoi(p)
+		params = append(params, n)
+	}
+	return params
+}
+
+// param returns the i-th parameter, or def when it is missing or zero.
+func param(params []int, i, def int) int {
+	if i >= len(params) || params[i] == 0 {
+		return def
+	}
+	return params[i]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func newLine(width int, blank termCell) []termCell {
+	line := make([]termCell, width)
+	for i := range line {
+		line[i] = blank
+	}
+	return line
+}
+
+func resizeCells(old [][]termCell, width, height, offset int) [][]termCell {
+	cells := newCells(width, height, termCell{r: ' ', style: tcell.StyleDefault})
+	for y := 0; y < height && y+offset < len(old); y++ {
+		copy(cells[y], old[y+offset])
+	}
+	return cells
+}
+
+func newCells(width, height int, blank termCell) [][]termCell {
+	cells := make([][]termCell, height)
+	for i := range cells {
+		cells[i] = newLine(width, blank)
+	}
+	return cells
+}
@@ -0,0 +1,223 @@
+package throwing
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell"
+)
+
+// screenLines returns the text of the screen, without the blanks at the end of the lines.
+func screenLines(t *TerminalView) []string {
+	var lines []string
+	for _, line := range t.cells {
+		b := &strings.Builder{}
+		for _, c := range line {
+			b.WriteRune(c.r)
+		}
+		lines = append(lines, strings.TrimRight(b.String(), " "))
+	}
+	return lines
+}
+
+func TestTerminalEscapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  []string
+		lines   map[int]string
+		cursorX int
+		cursorY int
+	}{
+		{
+			name:    "text and newlines",
+			output:  []string{"hello\r\nworld"},
+			lines:   map[int]string{0: "hello", 1: "world"},
+			cursorX: 5,
+			cursorY: 1,
+		},
+		{
+			name:    "cursor position",
+			output:  []string{"\x1b[3;5Hx"},
+			lines:   map[int]string{2: "    x"},
+			cursorX: 5,
+			cursorY: 2,
+		},
+		{
+			name:    "erase line",
+			output:  []string{"abcdef\x1b[3D\x1b[K"},
+			lines:   map[int]string{0: "abc"},
+			cursorX: 3,
+		},
+		{
+			name:   "erase display",
+			output: []string{"abc\r\ndef\x1b[2J\x1b[H"},
+			lines:  map[int]string{0: "", 1: ""},
+		},
+		{
+			name:    "split sequences and runes",
+			output:  []string{"\x1b[", "2;2H\xc3", "\xa9"},
+			lines:   map[int]string{1: " é"},
+			cursorX: 2,
+			cursorY: 1,
+		},
+		{
+			name:    "alternate screen restored",
+			output:  []string{"shell", "\x1b[?1049h\x1b[Hvim", "\x1b[?1049l"},
+			lines:   map[int]string{0: "shell"},
+			cursorX: 5,
+		},
+		{
+			name:    "window title ignored",
+			output:  []string{"\x1b]0;title\x07ok"},
+			lines:   map[int]string{0: "ok"},
+			cursorX: 2,
+		},
+		{
+			name:    "backspace and tab",
+			output:  []string{"ab\bc\tx"},
+			lines:   map[int]string{0: "ac      x"},
+			cursorX: 9,
+		},
+	}
+	for _, tt := range tests {
+		term := NewTerminalView(ioutil.Discard)
+		for _, s := range tt.output {
+			term.Write([]byte(s))
+		}
+		lines := screenLines(term)
+		for y, want := range tt.lines {
+			if lines[y] != want {
+				t.Errorf("%s: line %d = %q, want %q", tt.name, y, lines[y], want)
+			}
+		}
+		if term.cursorX != tt.cursorX || term.cursorY != tt.cursorY {
+			t.Errorf("%s: cursor = %d,%d, want %d,%d", tt.name, term.cursorX, term.cursorY, tt.cursorX, tt.cursorY)
+		}
+		term.Close()
+	}
+}
+
+func TestTerminalColors(t *testing.T) {
+	term := NewTerminalView(ioutil.Discard)
+	defer term.Close()
+	term.Write([]byte("\x1b[1;31mr\x1b[0mn"))
+	fg, _, attrs := term.cells[0][0].style.Decompose()
+	if fg != tcell.ColorMaroon || attrs&tcell.AttrBold == 0 {
+		t.Errorf("styled cell = %v %v, want maroon bold", fg, attrs)
+	}
+	if term.cells[0][1].style != tcell.StyleDefault {
+		t.Errorf("cell after reset = %v, want default", term.cells[0][1].style)
+	}
+}
+
+// syncBuffer is a buffer the writer of a terminal can write to while the test reads it.
+type syncBuffer struct {
+	lock sync.Mutex
+	b    bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.b.String()
+}
+
+func waitFor(t *testing.T, input *syncBuffer, want string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for input.String() != want && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := input.String(); got != want {
+		t.Errorf("input = %q, want %q", got, want)
+	}
+}
+
+func TestTerminalReplies(t *testing.T) {
+	input := &syncBuffer{}
+	term := NewTerminalView(input)
+	defer term.Close()
+	term.Write([]byte("\x1b[5;10H\x1b[6n\x1b[5n"))
+	waitFor(t, input, "\x1b[5;10R\x1b[0n")
+}
+
+// slowWriter blocks until released, like a remote shell that does not read its input.
+type slowWriter struct {
+	syncBuffer
+	release chan struct{}
+}
+
+func (s *slowWriter) Write(p []byte) (int, error) {
+	<-s.release
+	return s.syncBuffer.Write(p)
+}
+
+func TestTerminalKeysNotDropped(t *testing.T) {
+	input := &slowWriter{release: make(chan struct{})}
+	term := NewTerminalView(input)
+	defer term.Close()
+
+	want := &strings.Builder{}
+	handler := term.InputHandler()
+	for i := 0; i < 500; i++ {
+		r := rune('a' + i%26)
+		handler(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), nil)
+		want.WriteRune(r)
+	}
+	close(input.release)
+	waitFor(t, &input.syncBuffer, want.String())
+}
+
+func TestTerminalResizesInOrder(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer screen.Fini()
+	screen.SetSize(200, 100)
+
+	release := make(chan struct{})
+	lock := sync.Mutex{}
+	var sizes [][2]int
+	term := NewTerminalView(ioutil.Discard)
+	defer term.Close()
+	term.SetResizedFunc(func(width, height int) {
+		<-release
+		lock.Lock()
+		defer lock.Unlock()
+		sizes = append(sizes, [2]int{width, height})
+	})
+
+	// the handler is stuck on the first size while the others are drawn, only the last one has to follow it
+	for i := 1; i <= 20; i++ {
+		term.SetRect(0, 0, 80+i, 24+i)
+		term.Draw(screen)
+	}
+	close(release)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		lock.Lock()
+		got := append([][2]int{}, sizes...)
+		lock.Unlock()
+		if len(got) > 0 && got[len(got)-1] == [2]int{100, 44} {
+			if len(got) > 2 {
+				t.Errorf("sizes = %v, want only the first and the latest size", got)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("sizes = %v, want them to end with [100 44]", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,183 @@
+package throwing
+
+import (
+	"github.com/gdamore/tcell"
+	"github.com/rancher/axe/throwing/datafeeder"
+	"github.com/rancher/axe/throwing/types"
+	"github.com/rivo/tview"
+)
+
+// TreeObject is the object a node of a TreeView stands for. Nodes that do not reference one, like groups, have no actions.
+type TreeObject struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// TreeBuilder builds the nodes of a TreeView. It is called again on every refresh.
+type TreeBuilder func() (*tview.TreeNode, error)
+
+/*
+TreeView shows objects as a tree of collapsible nodes. The row actions of a table are available on the nodes: the tree
+keeps a table holding only the object of the current node, which is what the actions read their selection from.
+*/
+type TreeView struct {
+	*tview.TreeView
+
+	table *TableView
+	build TreeBuilder
+}
+
+func (t *TableView) NewTreeView(kind types.ResourceKind, build TreeBuilder, actions []types.Action, handler EventHandler) *TreeView {
+	tv := &TreeView{
+		TreeView: tview.NewTreeView(),
+		build:    build,
+	}
+	tv.table = &TableView{
+		Table:  tview.NewTable(),
+		drawer: t.drawer,
+		front:  tv,
+	}
+	tv.table.init(t.app, kind, treeSource{tv}, actions, nil, handler)
+
+	tv.TreeView.SetBorder(true)
+	tv.TreeView.SetBackgroundColor(tcell.ColorBlack)
+	tv.TreeView.SetBorderAttributes(tcell.AttrBold)
+	tv.TreeView.SetTitle(kind.Title)
+	tv.TreeView.SetGraphicsColor(tcell.ColorGray)
+	tv.TreeView.SetSelectedFunc(func(node *tview.TreeNode) {
+		node.SetExpanded(!node.IsExpanded())
+	})
+	tv.TreeView.SetChangedFunc(tv.selectNode)
+
+	keys := handler(tv.table)
+	tv.TreeView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEscape:
+			tv.table.BackPage()
+			return nil
+		case tcell.KeyRune:
+			switch event.Rune() {
+			case 'j', 'k', 'G':
+				return event
+			case 'r':
+				if err := tv.Refresh(); err != nil {
+					tv.table.UpdateStatus(err.Error(), true)
+				}
+				return nil
+			}
+			if node := tv.GetCurrentNode(); node != nil {
+				if _, ok := node.GetReference().(TreeObject); ok {
+					keys(event)
+				}
+			}
+			return nil
+		}
+		return event
+	})
+
+	if err := tv.Refresh(); err != nil {
+		t.UpdateStatus(err.Error(), true)
+	}
+	return tv
+}
+
+// Table returns the table holding the object of the current node, which is the table to register the tree's page with.
+func (tv *TreeView) Table() *TableView {
+	return tv.table
+}
+
+// Refresh rebuilds the tree. Collapsed nodes and the current node are kept if they are still there.
+func (tv *TreeView) Refresh() error {
+	root, err := tv.build()
+	if err != nil {
+		return err
+	}
+
+	collapsed := map[string]bool{}
+	current := ""
+	if old := tv.GetRoot(); old != nil {
+		walkTree(old, "", func(node *tview.TreeNode, path string) {
+			if !node.IsExpanded() {
+				collapsed[path] = true
+			}
+			if node == tv.GetCurrentNode() {
+				current = path
+			}
+		})
+	}
+
+	node := root
+	walkTree(root, "", func(n *tview.TreeNode, path string) {
+		if collapsed[path] {
+			n.Collapse()
+		}
+		if path == current {
+			node = n
+		}
+	})
+	tv.SetRoot(root).SetCurrentNode(node)
+	tv.selectNode(node)
+	return nil
+}
+
+// selectNode points the table at the object of the node, so that the actions apply to it.
+func (tv *TreeView) selectNode(node *tview.TreeNode) {
+	object, _ := node.GetReference().(TreeObject)
+	tv.table.resourceKind.Kind = object.Kind
+	tv.table.draw()
+	tv.table.Select(1, 0)
+}
+
+// currentObject returns the object of the current node, if it has one.
+func (tv *TreeView) currentObject() (TreeObject, bool) {
+	node := tv.GetCurrentNode()
+	if node == nil {
+		return TreeObject{}, false
+	}
+	object, ok := node.GetReference().(TreeObject)
+	return object, ok
+}
+
+/*
+treeSource is the DataSource of the table of a tree: one row with the object of the current node. Refreshing it, as
+actions do once they are done, rebuilds the tree.
+*/
+type treeSource struct {
+	tv *TreeView
+}
+
+func (s treeSource) Header() datafeeder.Row {
+	if object, ok := s.tv.currentObject(); ok && object.Namespace == "" {
+		return datafeeder.Row{"NAME"}
+	}
+	return datafeeder.Row{"NAMESPACE", "NAME"}
+}
+
+func (s treeSource) Data() []datafeeder.Row {
+	object, ok := s.tv.currentObject()
+	if !ok {
+		return nil
+	}
+	if object.Namespace == "" {
+		return []datafeeder.Row{{object.Name}}
+	}
+	return []datafeeder.Row{{object.Namespace, object.Name}}
+}
+
+func (s treeSource) Refresh() error {
+	return s.tv.Refresh()
+}
+
+// walkTree calls fn for every node with a path that identifies the node across rebuilds of the tree.
+func walkTree(node *tview.TreeNode, parent string, fn func(node *tview.TreeNode, path string)) {
+	key := node.GetText()
+	if object, ok := node.GetReference().(TreeObject); ok {
+		key = object.Kind + "/" + object.Namespace + "/" + object.Name
+	}
+	path := parent + "|" + key
+	fn(node, path)
+	for _, child := range node.GetChildren() {
+		walkTree(child, path, fn)
+	}
+}